@@ -23,6 +23,7 @@ func main() {
 	}
 
 	database.ConnectDB()
+	database.EnsureIndexes()
 	services.InitAI()
 
 	mux := http.NewServeMux()
@@ -86,8 +87,45 @@ func main() {
 	}))
 	mux.HandleFunc("/api/working-hours/stats", auth.Protect(handlers.GetWorkingHoursStats))
 	mux.HandleFunc("/api/working-hours/categories", auth.Protect(handlers.GetWorkingHoursCategories))
+	mux.HandleFunc("/api/working-hours/series", auth.Protect(handlers.GetWorkingHoursSeries))
+	mux.HandleFunc("/api/working-hours/mood-stats", auth.Protect(handlers.GetWorkingHoursMoodStats))
+	mux.HandleFunc("/api/working-hours/projection", auth.Protect(handlers.GetWorkingHoursProjection))
+	mux.HandleFunc("/api/working-hours/template", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetWorkingHoursTemplate(w, r)
+		case http.MethodPut:
+			handlers.UpdateWorkingHoursTemplate(w, r)
+		case http.MethodDelete:
+			handlers.DeleteWorkingHoursTemplate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.HandleFunc("/api/working-hours/goals", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetWorkingHoursGoals(w, r)
+		case http.MethodPost:
+			handlers.CreateWorkingHoursGoal(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
 
 	mux.HandleFunc("/api/working-hours/", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		// /api/working-hours/goals/:id
+		if strings.Contains(r.URL.Path, "/goals/") {
+			if r.Method == http.MethodPut || r.Method == http.MethodPatch {
+				handlers.UpdateWorkingHoursGoal(w, r)
+			} else if r.Method == http.MethodDelete {
+				handlers.DeleteWorkingHoursGoal(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
 		if strings.HasSuffix(r.URL.Path, "/stats") {
 			handlers.GetWorkingHoursStats(w, r)
 			return
@@ -96,8 +134,28 @@ func main() {
 			handlers.GetWorkingHoursCategories(w, r)
 			return
 		}
+		if strings.HasSuffix(r.URL.Path, "/series") {
+			handlers.GetWorkingHoursSeries(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/mood-stats") {
+			handlers.GetWorkingHoursMoodStats(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/export") {
+			handlers.ExportWorkingHours(w, r)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/import") {
+			if r.Method == http.MethodPost {
+				handlers.ImportWorkingHours(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
 
-		if r.Method == http.MethodPut {
+		if r.Method == http.MethodPut || r.Method == http.MethodPatch {
 			handlers.UpdateWorkingHours(w, r)
 		} else if r.Method == http.MethodDelete {
 			handlers.DeleteWorkingHours(w, r)
@@ -106,6 +164,52 @@ func main() {
 		}
 	}))
 
+	// Activity
+	mux.HandleFunc("/api/activity/heatmap", auth.Protect(handlers.GetActivityHeatmap))
+
+	// Focus sessions
+	mux.HandleFunc("/api/focus/settings", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetFocusSettings(w, r)
+		case http.MethodPut, http.MethodPatch:
+			handlers.UpdateFocusSettings(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.HandleFunc("/api/focus/stats", auth.Protect(handlers.GetFocusStats))
+	mux.HandleFunc("/api/focus/sessions", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetFocusSessions(w, r)
+		case http.MethodPost:
+			handlers.StartFocusSession(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.HandleFunc("/api/focus/sessions/", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		// /api/focus/sessions/active
+		// /api/focus/sessions/:id/complete
+		// /api/focus/sessions/:id/interrupt
+		if strings.HasSuffix(r.URL.Path, "/active") && r.Method == http.MethodGet {
+			handlers.GetActiveFocusSession(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/complete") {
+			handlers.CompleteFocusSession(w, r)
+		} else if strings.HasSuffix(r.URL.Path, "/interrupt") {
+			handlers.InterruptFocusSession(w, r)
+		} else {
+			http.NotFound(w, r)
+		}
+	}))
+
 	// Skills
 	mux.HandleFunc("/api/skills", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -121,6 +225,19 @@ func main() {
 	}))
 	mux.HandleFunc("/api/skills/stats", auth.Protect(handlers.GetSkillStats))
 	mux.HandleFunc("/api/skills/categories", auth.Protect(handlers.GetSkillCategories))
+	mux.HandleFunc("/api/skills/velocity", auth.Protect(handlers.GetSkillVelocity))
+	mux.HandleFunc("/api/skills/roadmap", auth.Protect(handlers.GetSkillRoadmap))
+	mux.HandleFunc("/api/skills/next", auth.Protect(handlers.GetNextSkills))
+	mux.HandleFunc("/api/skills/time", auth.Protect(handlers.GetSkillTimeInvested))
+	mux.HandleFunc("/api/skills/export", auth.Protect(handlers.ExportSkillTemplate))
+	mux.HandleFunc("/api/skills/reviews/due", auth.Protect(handlers.GetDueSkillReviews))
+	mux.HandleFunc("/api/skills/import", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handlers.ImportSkillTemplate(w, r)
+	}))
 	mux.HandleFunc("/api/skills/", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		// Handlers above should catch exact matches, but suffix check here too if needed
 		if strings.HasSuffix(r.URL.Path, "/stats") {
@@ -131,8 +248,76 @@ func main() {
 			handlers.GetSkillCategories(w, r)
 			return
 		}
+		// /api/skills/:id/resources
+		// /api/skills/:id/resources/reorder
+		// /api/skills/:id/resources/:resourceId
+		if strings.Contains(r.URL.Path, "/resources") {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/resources") && r.Method == http.MethodPost:
+				handlers.AddSkillResource(w, r)
+			case strings.HasSuffix(r.URL.Path, "/resources/reorder") && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+				handlers.ReorderSkillResources(w, r)
+			case r.Method == http.MethodPut || r.Method == http.MethodPatch:
+				handlers.UpdateSkillResource(w, r)
+			case r.Method == http.MethodDelete:
+				handlers.DeleteSkillResource(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		// /api/skills/:id/milestones
+		// /api/skills/:id/milestones/reorder
+		// /api/skills/:id/milestones/:milestoneId
+		if strings.Contains(r.URL.Path, "/milestones") {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/milestones") && r.Method == http.MethodPost:
+				handlers.AddSkillMilestone(w, r)
+			case strings.HasSuffix(r.URL.Path, "/milestones/reorder") && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+				handlers.ReorderSkillMilestones(w, r)
+			case r.Method == http.MethodPut || r.Method == http.MethodPatch:
+				handlers.UpdateSkillMilestone(w, r)
+			case r.Method == http.MethodDelete:
+				handlers.DeleteSkillMilestone(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/prerequisites") {
+			if r.Method == http.MethodPut {
+				handlers.UpdateSkillPrerequisites(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/timeline") {
+			handlers.GetSkillTimeline(w, r)
+			return
+		}
+		// /api/skills/:id/review
+		if strings.HasSuffix(r.URL.Path, "/review") {
+			switch r.Method {
+			case http.MethodGet:
+				handlers.GetSkillReviews(w, r)
+			case http.MethodPost:
+				handlers.SubmitSkillReview(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/reorder") {
+			if r.Method == http.MethodPost || r.Method == http.MethodPut {
+				handlers.ReorderSkills(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
 
-		if r.Method == http.MethodPut {
+		if r.Method == http.MethodPut || r.Method == http.MethodPatch {
 			handlers.UpdateSkill(w, r)
 		} else if r.Method == http.MethodDelete {
 			handlers.DeleteSkill(w, r)
@@ -153,6 +338,21 @@ func main() {
 		}
 	}))
 	mux.HandleFunc("/api/schedules/categories", auth.Protect(handlers.GetScheduleCategories))
+	mux.HandleFunc("/api/schedules/conflicts", auth.Protect(handlers.GetScheduleConflicts))
+	mux.HandleFunc("/api/schedules/rollover", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handlers.RolloverSchedule(w, r)
+	}))
+	mux.HandleFunc("/api/schedules/import", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handlers.ImportSchedules(w, r)
+	}))
 
 	mux.HandleFunc("/api/schedules/", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		if strings.HasSuffix(r.URL.Path, "/categories") {
@@ -160,6 +360,12 @@ func main() {
 			return
 		}
 
+		// /api/schedules/:id/duplicate
+		if strings.HasSuffix(r.URL.Path, "/duplicate") && r.Method == http.MethodPost {
+			handlers.DuplicateSchedule(w, r)
+			return
+		}
+
 		// Items
 		// /api/schedules/:id/items
 		// /api/schedules/:id/items/:itemId
@@ -169,7 +375,7 @@ func main() {
 				return
 			}
 			// itemId in path
-			if r.Method == http.MethodPut {
+			if r.Method == http.MethodPut || r.Method == http.MethodPatch {
 				handlers.UpdateScheduleItem(w, r)
 				return
 			}
@@ -179,7 +385,7 @@ func main() {
 			}
 		}
 
-		if r.Method == http.MethodPut {
+		if r.Method == http.MethodPut || r.Method == http.MethodPatch {
 			handlers.UpdateSchedule(w, r)
 		} else if r.Method == http.MethodDelete {
 			handlers.DeleteSchedule(w, r)
@@ -188,6 +394,67 @@ func main() {
 		}
 	}))
 
+	// Calendar feed. Calendar apps fetch the feed itself with the token in
+	// its URL rather than a JWT.
+	mux.HandleFunc("/api/calendar/feed-token", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			handlers.CreateCalendarFeedToken(w, r)
+		case http.MethodDelete:
+			handlers.RevokeCalendarFeedToken(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.HandleFunc("/api/calendar/feed/", handlers.GetCalendarFeed)
+
+	// Schedule templates
+	mux.HandleFunc("/api/schedule-templates", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetScheduleTemplates(w, r)
+		case http.MethodPost:
+			handlers.CreateScheduleTemplate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	mux.HandleFunc("/api/schedule-templates/", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
+		// /api/schedule-templates/:id/materialize
+		if strings.HasSuffix(r.URL.Path, "/materialize") {
+			if r.Method == http.MethodPost {
+				handlers.MaterializeScheduleTemplate(w, r)
+			} else {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+		// /api/schedule-templates/:id/exceptions
+		// /api/schedule-templates/:id/exceptions/:date
+		if strings.Contains(r.URL.Path, "/exceptions") {
+			switch {
+			case strings.HasSuffix(r.URL.Path, "/exceptions") && (r.Method == http.MethodPost || r.Method == http.MethodPut):
+				handlers.SetScheduleException(w, r)
+			case r.Method == http.MethodDelete:
+				handlers.DeleteScheduleException(w, r)
+			default:
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			handlers.GetScheduleTemplate(w, r)
+		case http.MethodPut, http.MethodPatch:
+			handlers.UpdateScheduleTemplate(w, r)
+		case http.MethodDelete:
+			handlers.DeleteScheduleTemplate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
 	// Timetables
 	mux.HandleFunc("/api/timetables", auth.Protect(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
@@ -258,7 +525,7 @@ func main() {
 		w.Write([]byte(`{"status":"success","message":"API is running","serverTime":"` + time.Now().Format(time.RFC3339) + `"}`))
 	})
 
-	handler := CorsMiddleware(LoggerMiddleware(mux))
+	handler := CorsMiddleware(LoggerMiddleware(handlers.CacheUserLocation(mux)))
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -303,7 +570,7 @@ func CorsMiddleware(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
@@ -0,0 +1,132 @@
+// Command dedupe-schedules merges schedules that share a user and date into
+// one, then replaces the plain {user, date} index with the unique one the API
+// now relies on. The items of the later duplicates are appended to the
+// oldest schedule, which is kept. Running it again changes nothing.
+//
+// Duplicates are found by their stored date, so run cmd/migrate-dates first:
+// schedules that only land on the same day once re-anchored would otherwise
+// be missed.
+//
+// Usage:
+//
+//	go run ./cmd/dedupe-schedules [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const dateIndexName = "user_1_date_1"
+
+// dropPlainDateIndex removes the non-unique {user, date} index so that
+// EnsureIndexes can create the unique one under the same name.
+func dropPlainDateIndex(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return err
+	}
+	var indexes []struct {
+		Name   string `bson:"name"`
+		Unique bool   `bson:"unique"`
+	}
+	if err = cursor.All(ctx, &indexes); err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if index.Name == dateIndexName && !index.Unique {
+			_, err := collection.Indexes().DropOne(ctx, dateIndexName)
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the duplicates that would be merged without writing anything")
+	flag.Parse()
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+	}
+
+	database.ConnectDB()
+
+	ctx := context.Background()
+	collection := database.GetCollection("schedules")
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"user": "$user", "date": "$date"},
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var groups []struct {
+		IDs []primitive.ObjectID `bson:"ids"`
+	}
+	if err = cursor.All(ctx, &groups); err != nil {
+		log.Fatal(err)
+	}
+
+	removed := 0
+	for _, group := range groups {
+		opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+		cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": group.IDs}}, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var schedules []models.Schedule
+		if err = cursor.All(ctx, &schedules); err != nil {
+			log.Fatal(err)
+		}
+		if len(schedules) < 2 {
+			continue
+		}
+
+		kept := models.MergeSchedules(schedules)
+		log.Printf("%s %s: merging %d schedules into %s",
+			kept.User.Hex(), kept.Date.Format(time.RFC3339), len(schedules), kept.ID.Hex())
+		removed += len(schedules) - 1
+		if *dryRun {
+			continue
+		}
+
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": kept.ID}, bson.M{"$set": kept}); err != nil {
+			log.Fatalf("schedule %s: %v", kept.ID.Hex(), err)
+		}
+		duplicates := make([]primitive.ObjectID, 0, len(schedules)-1)
+		for _, s := range schedules[1:] {
+			duplicates = append(duplicates, s.ID)
+		}
+		if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": duplicates}}); err != nil {
+			log.Fatalf("duplicates of schedule %s: %v", kept.ID.Hex(), err)
+		}
+	}
+
+	if *dryRun {
+		log.Printf("Dry run complete, %d duplicate schedules would be merged", removed)
+		return
+	}
+	if err := dropPlainDateIndex(ctx, collection); err != nil {
+		log.Fatal(err)
+	}
+	database.EnsureIndexes()
+	log.Printf("✅ Merged %d duplicate schedules", removed)
+}
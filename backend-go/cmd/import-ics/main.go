@@ -0,0 +1,131 @@
+// Command import-ics adds the events of an iCalendar file to a user's
+// schedules, like POST /api/schedules/import. Importing the same file again
+// only adds events it has not seen.
+//
+// Usage:
+//
+//	go run ./cmd/import-ics -email you@example.com -file calendar.ics -from 2026-01-01 -to 2026-01-31 \
+//		[-rule gym=Health -rule standup=Work] [-default-category Other] [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/ical"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/scheduleimport"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func findUser(ctx context.Context, email, id string) (*models.User, error) {
+	filter := bson.M{"email": strings.TrimSpace(email)}
+	if id != "" {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"_id": objID}
+	}
+	var user models.User
+	if err := database.GetCollection("users").FindOne(ctx, filter).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func main() {
+	email := flag.String("email", "", "email of the user to import into")
+	userID := flag.String("user", "", "ID of the user, instead of -email")
+	file := flag.String("file", "", "iCalendar file to import")
+	fromStr := flag.String("from", "", "first day to import (YYYY-MM-DD)")
+	toStr := flag.String("to", "", "last day to import (YYYY-MM-DD)")
+	defaultCategory := flag.String("default-category", "", "category for events no rule matches (default Other)")
+	dryRun := flag.Bool("dry-run", false, "report what the import would change without writing it")
+	var rules []scheduleimport.CategoryRule
+	flag.Func("rule", "keyword=Category; events whose summary contains keyword get Category (repeatable)", func(s string) error {
+		keyword, category, ok := strings.Cut(s, "=")
+		if !ok || strings.TrimSpace(keyword) == "" || strings.TrimSpace(category) == "" {
+			return fmt.Errorf("rule must be keyword=Category")
+		}
+		rules = append(rules, scheduleimport.CategoryRule{Keyword: strings.TrimSpace(keyword), Category: strings.TrimSpace(category)})
+		return nil
+	})
+	flag.Parse()
+
+	if (*email == "") == (*userID == "") || *file == "" || *fromStr == "" || *toStr == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+	}
+
+	database.ConnectDB()
+
+	ctx := context.Background()
+	user, err := findUser(ctx, *email, *userID)
+	if err != nil {
+		log.Fatalf("User not found: %v", err)
+	}
+	loc := timeutil.Location(user.Timezone)
+
+	from, err := timeutil.ParseDate(*fromStr, loc)
+	if err != nil {
+		log.Fatalf("Invalid -from: %v", err)
+	}
+	to, err := timeutil.ParseDate(*toStr, loc)
+	if err != nil {
+		log.Fatalf("Invalid -to: %v", err)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	events, err := ical.Parse(f, loc)
+	if err != nil {
+		log.Fatalf("%s: %v", *file, err)
+	}
+
+	report, err := scheduleimport.Import(ctx, user.ID, events, scheduleimport.Options{
+		From:            from,
+		To:              to,
+		Location:        loc,
+		Rules:           rules,
+		DefaultCategory: strings.TrimSpace(*defaultCategory),
+		Strict:          user.ScheduleConflicts == models.ScheduleConflictsStrict,
+		DryRun:          *dryRun,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, s := range report.Skipped {
+		log.Printf("- %s %s: %s", s.Date, s.Summary, s.Reason)
+	}
+	for _, c := range report.Conflicts {
+		log.Printf("⚠️ %s %s (%s-%s) overlaps %s (%s-%s)", c.Date,
+			c.Item.Title, c.Item.StartTime, c.Item.EndTime, c.With.Title, c.With.StartTime, c.With.EndTime)
+	}
+	log.Printf("%d items added, %d duplicates, %d skipped, %d conflicts; %d schedules created, %d updated",
+		report.ItemsAdded, report.Duplicates, len(report.Skipped), len(report.Conflicts), report.SchedulesCreated, report.SchedulesUpdated)
+
+	if *dryRun {
+		log.Println("Dry run complete, nothing was written")
+	} else {
+		log.Printf("✅ Imported %s", *file)
+	}
+}
@@ -0,0 +1,122 @@
+// Command import-roadmap imports a skill roadmap template into a user's
+// account, or exports a user's skills as one. Importing the same template
+// again only adds what is missing.
+//
+// Usage:
+//
+//	go run ./cmd/import-roadmap -email you@example.com -file roadmaps/starter.yaml [-dry-run]
+//	go run ./cmd/import-roadmap -email you@example.com -export roadmap.yaml
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/roadmap"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func findUser(ctx context.Context, email, id string) (*models.User, error) {
+	filter := bson.M{"email": strings.TrimSpace(email)}
+	if id != "" {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"_id": objID}
+	}
+	var user models.User
+	if err := database.GetCollection("users").FindOne(ctx, filter).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func main() {
+	email := flag.String("email", "", "email of the user to import into or export from")
+	userID := flag.String("user", "", "ID of the user, instead of -email")
+	file := flag.String("file", "", "template to import (.yaml, .yml or .json)")
+	export := flag.String("export", "", "write the user's skills to this file instead of importing")
+	name := flag.String("name", "", "name of the exported roadmap")
+	dryRun := flag.Bool("dry-run", false, "report what the import would change without writing it")
+	flag.Parse()
+
+	if (*email == "") == (*userID == "") || (*file == "") == (*export == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+	}
+
+	database.ConnectDB()
+
+	ctx := context.Background()
+	user, err := findUser(ctx, *email, *userID)
+	if err != nil {
+		log.Fatalf("User not found: %v", err)
+	}
+
+	if *export != "" {
+		if *name == "" {
+			*name = "My roadmap"
+		}
+		template, err := roadmap.Export(ctx, user.ID, *name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		out, err := os.Create(*export)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer out.Close()
+		if err := roadmap.Encode(out, template, roadmap.FormatOf(*export)); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("✅ Exported %d categories to %s", len(template.Categories), *export)
+		return
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	template, err := roadmap.Parse(data, roadmap.FormatOf(*file))
+	if err != nil {
+		log.Fatalf("%s: %v", *file, err)
+	}
+
+	report, err := roadmap.Import(ctx, user.ID, template, timeutil.Location(user.Timezone), *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, c := range report.CategoriesCreated {
+		log.Printf("+ category %s", c)
+	}
+	for _, s := range report.SkillsCreated {
+		log.Printf("+ skill %s", s)
+	}
+	for _, s := range report.SkillsUpdated {
+		log.Printf("~ skill %s", s)
+	}
+	log.Printf("%d skills created, %d updated, %d unchanged, %d resources added",
+		len(report.SkillsCreated), len(report.SkillsUpdated), report.SkillsUnchanged, report.ResourcesAdded)
+
+	if *dryRun {
+		log.Println("Dry run complete, nothing was written")
+	} else {
+		log.Printf("✅ Imported '%s'", template.Name)
+	}
+}
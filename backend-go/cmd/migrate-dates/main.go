@@ -0,0 +1,242 @@
+// Command migrate-dates re-anchors stored dates to midnight in each user's
+// timezone, matching how the API now cuts day and week boundaries. Schedules
+// that end up on the same day are merged into the oldest one.
+//
+// Run it before cmd/dedupe-schedules, which only finds duplicates by their
+// stored date, and before the unique {user, date} schedules index exists,
+// which the API creates when it starts.
+//
+// Usage:
+//
+//	go run ./cmd/migrate-dates [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// legacyDay picks the calendar day a stored date was meant to represent.
+// Plain YYYY-MM-DD input used to be stored at UTC midnight, so those dates
+// keep their UTC calendar day; anything else is read in the user's timezone.
+// Dates that are already at local midnight map onto themselves, which keeps
+// the migration safe to re-run.
+func legacyDay(date time.Time, loc *time.Location) time.Time {
+	utc := date.UTC()
+	if utc.Hour() == 0 && utc.Minute() == 0 && utc.Second() == 0 && utc.Nanosecond() == 0 {
+		y, m, d := utc.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	return timeutil.StartOfDay(date, loc)
+}
+
+// dateDoc is a document's ID and stored date.
+type dateDoc struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Date time.Time          `bson:"date"`
+}
+
+// migrateDates re-anchors the dates of a user's documents and returns how
+// many moved or were merged away. Documents can collide on a day, such as
+// one stored at UTC midnight and one at 20:00Z. Schedules, which must be one
+// per day, are then merged with mergeScheduleDay; working hours are moved
+// anyway and reported.
+func migrateDates(ctx context.Context, collectionName string, userObjID primitive.ObjectID, loc *time.Location, dryRun bool) (int, error) {
+	collection := database.GetCollection(collectionName)
+	opts := options.Find().
+		SetProjection(bson.M{"date": 1}).
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"user": userObjID}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []dateDoc
+	if err = cursor.All(ctx, &docs); err != nil {
+		return 0, err
+	}
+
+	byDay := map[time.Time][]dateDoc{}
+	var days []time.Time
+	for _, doc := range docs {
+		day := legacyDay(doc.Date, loc)
+		if byDay[day] == nil {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], doc)
+	}
+
+	moved := 0
+	writes := []mongo.WriteModel{}
+	for _, day := range days {
+		group := byDay[day]
+		if len(group) > 1 {
+			log.Printf("⚠️ %s: %d %s documents fall on %s", userObjID.Hex(), len(group), collectionName, day.Format(timeutil.DateLayout))
+			if collectionName == "schedules" {
+				if err := mergeScheduleDay(ctx, collection, group, day, dryRun); err != nil {
+					return moved, err
+				}
+				// group[0] is the oldest schedule, the one that is kept.
+				moved += len(group) - 1
+				if !day.Equal(group[0].Date) {
+					moved++
+				}
+				continue
+			}
+		}
+		for _, doc := range group {
+			if day.Equal(doc.Date) {
+				continue
+			}
+			moved++
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": doc.ID}).
+				SetUpdate(bson.M{"$set": bson.M{"date": day}}))
+		}
+	}
+
+	if len(writes) == 0 || dryRun {
+		return moved, nil
+	}
+	if _, err := collection.BulkWrite(ctx, writes); err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// mergeScheduleDay merges schedules that re-anchor onto the same day into
+// the oldest one, as cmd/dedupe-schedules does, and moves it to day. The
+// others are deleted first, so the unique {user, date} index never sees two
+// schedules on day.
+func mergeScheduleDay(ctx context.Context, collection *mongo.Collection, group []dateDoc, day time.Time, dryRun bool) error {
+	ids := make([]primitive.ObjectID, len(group))
+	for i, doc := range group {
+		ids[i] = doc.ID
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return err
+	}
+	var schedules []models.Schedule
+	if err = cursor.All(ctx, &schedules); err != nil {
+		return err
+	}
+
+	kept := models.MergeSchedules(schedules)
+	kept.Date = day
+	log.Printf("%s %s: merging %d schedules into %s", kept.User.Hex(), day.Format(timeutil.DateLayout), len(schedules), kept.ID.Hex())
+	if dryRun {
+		return nil
+	}
+
+	duplicates := make([]primitive.ObjectID, 0, len(schedules)-1)
+	for _, s := range schedules[1:] {
+		duplicates = append(duplicates, s.ID)
+	}
+	if _, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": duplicates}}); err != nil {
+		return err
+	}
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": kept.ID}, bson.M{"$set": kept})
+	return err
+}
+
+func migrateCurrentWeeks(ctx context.Context, userObjID primitive.ObjectID, loc *time.Location, dryRun bool) (int, error) {
+	collection := database.GetCollection("timetables")
+	cursor, err := collection.Find(ctx, bson.M{"user": userObjID})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var timetables []models.Timetable
+	if err = cursor.All(ctx, &timetables); err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, t := range timetables {
+		if t.CurrentWeek.WeekStartDate.IsZero() {
+			continue
+		}
+		start := timeutil.StartOfWeek(legacyDay(t.CurrentWeek.WeekStartDate, loc), loc)
+		end := timeutil.EndOfWeek(start, loc)
+		if start.Equal(t.CurrentWeek.WeekStartDate) && end.Equal(t.CurrentWeek.WeekEndDate) {
+			continue
+		}
+		changed++
+		if dryRun {
+			continue
+		}
+		_, err := collection.UpdateOne(ctx, bson.M{"_id": t.ID}, bson.M{"$set": bson.M{
+			"currentWeek.weekStartDate": start,
+			"currentWeek.weekEndDate":   end,
+		}})
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the documents that would change without writing them")
+	flag.Parse()
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+	}
+
+	database.ConnectDB()
+
+	ctx := context.Background()
+	cursor, err := database.GetCollection("users").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"timezone": 1}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var users []models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, user := range users {
+		loc := timeutil.Location(user.Timezone)
+		for _, name := range []string{"workinghours", "schedules"} {
+			count, err := migrateDates(ctx, name, user.ID, loc, *dryRun)
+			if err != nil {
+				log.Fatalf("%s for user %s: %v", name, user.ID.Hex(), err)
+			}
+			if count > 0 {
+				log.Printf("%s: %d %s dates re-anchored to %s", user.ID.Hex(), count, name, loc)
+			}
+		}
+		count, err := migrateCurrentWeeks(ctx, user.ID, loc, *dryRun)
+		if err != nil {
+			log.Fatalf("timetables for user %s: %v", user.ID.Hex(), err)
+		}
+		if count > 0 {
+			log.Printf("%s: %d timetable weeks re-anchored to %s", user.ID.Hex(), count, loc)
+		}
+	}
+
+	if *dryRun {
+		log.Println("Dry run complete, nothing was written")
+	} else {
+		log.Println("✅ Date migration complete")
+	}
+}
@@ -0,0 +1,103 @@
+// Command repair-skills brings stored skills in line with the status rules
+// the API now enforces (see models.Skill.Normalize), for example a completed
+// skill left at 30% or an upcoming one with a start date. Running it again
+// changes nothing.
+//
+// Usage:
+//
+//	go run ./cmd/repair-skills [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the skills that would change without writing them")
+	flag.Parse()
+
+	if err := godotenv.Load("../../.env"); err != nil {
+		if err := godotenv.Load(".env"); err != nil {
+			log.Println("No .env file found, using environment variables")
+		}
+	}
+
+	database.ConnectDB()
+
+	ctx := context.Background()
+	collection := database.GetCollection("skills")
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var skills []models.Skill
+	if err = cursor.All(ctx, &skills); err != nil {
+		log.Fatal(err)
+	}
+
+	now := time.Now()
+	var writes []mongo.WriteModel
+	var events []interface{}
+	for _, skill := range skills {
+		before := skill
+		fixes := skill.Normalize(now)
+		if len(fixes) == 0 {
+			continue
+		}
+		for _, fix := range fixes {
+			log.Printf("%s %s/%s: %s", skill.ID.Hex(), skill.Category, skill.Name, fix)
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": skill.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"status":         skill.Status,
+				"progress":       skill.Progress,
+				"startDate":      skill.StartDate,
+				"completionDate": skill.CompletionDate,
+				"review":         skill.Review,
+				"updatedAt":      now,
+			}}))
+		if skill.Status != before.Status || skill.Progress != before.Progress {
+			events = append(events, models.SkillProgressEvent{
+				ID:           primitive.NewObjectID(),
+				User:         skill.User,
+				Skill:        skill.ID,
+				Category:     skill.Category,
+				FromStatus:   before.Status,
+				ToStatus:     skill.Status,
+				FromProgress: before.Progress,
+				ToProgress:   skill.Progress,
+				CreatedAt:    now,
+			})
+		}
+	}
+
+	log.Printf("%d of %d skills need repair", len(writes), len(skills))
+	if *dryRun {
+		log.Println("Dry run complete, nothing was written")
+		return
+	}
+	if len(writes) > 0 {
+		if _, err := collection.BulkWrite(ctx, writes); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(events) > 0 {
+		if _, err := database.GetCollection("skill_progress_events").InsertMany(ctx, events); err != nil {
+			log.Printf("⚠️ Failed to record progress for repaired skills: %v", err)
+		}
+	}
+	log.Println("✅ Skill repair complete")
+}
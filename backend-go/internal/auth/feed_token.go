@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// NewFeedToken returns a random token for a calendar feed URL along with
+// the hash to store. Only the hash is kept, so a leaked database does not
+// expose working feed URLs.
+func NewFeedToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(b)
+	return token, HashFeedToken(token), nil
+}
+
+// HashFeedToken hashes a feed token for lookup.
+func HashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -54,3 +55,57 @@ func ConnectDB() {
 func GetCollection(collectionName string) *mongo.Collection {
 	return DB.Collection(collectionName)
 }
+
+// EnsureIndexes creates the indexes the handlers rely on. Creating an index
+// that already exists is a no-op, so this is safe to run on every start.
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := map[string][]mongo.IndexModel{
+		"users": {
+			// Calendar feeds look the user up by the hash of their token.
+			{Keys: bson.D{{Key: "calendarFeedTokenHash", Value: 1}}, Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"calendarFeedTokenHash": bson.M{"$exists": true}})},
+		},
+		"workinghours": {
+			{Keys: bson.D{{Key: "user", Value: 1}, {Key: "date", Value: 1}}},
+			{Keys: bson.D{{Key: "user", Value: 1}, {Key: "category", Value: 1}, {Key: "date", Value: 1}}},
+		},
+		"workinghourstemplates": {
+			{Keys: bson.D{{Key: "user", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"schedules": {
+			// One schedule per user and day. Databases from before this
+			// index need cmd/migrate-dates, then cmd/dedupe-schedules.
+			{Keys: bson.D{{Key: "user", Value: 1}, {Key: "date", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user", Value: 1}, {Key: "templateId", Value: 1}, {Key: "date", Value: 1}}},
+		},
+		"scheduletemplates": {
+			{Keys: bson.D{{Key: "user", Value: 1}}},
+		},
+		"skill_progress_events": {
+			{Keys: bson.D{{Key: "user", Value: 1}, {Key: "skill", Value: 1}, {Key: "createdAt", Value: 1}}},
+		},
+		"skill_reviews": {
+			{Keys: bson.D{{Key: "user", Value: 1}, {Key: "skill", Value: 1}, {Key: "createdAt", Value: 1}}},
+		},
+		"focussettings": {
+			{Keys: bson.D{{Key: "user", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"focussessions": {
+			{Keys: bson.D{{Key: "user", Value: 1}, {Key: "date", Value: 1}}},
+			// At most one running session per user.
+			{Keys: bson.D{{Key: "user", Value: 1}}, Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "active"})},
+		},
+	}
+
+	for name, models := range indexes {
+		if _, err := GetCollection(name).Indexes().CreateMany(ctx, models); err != nil {
+			log.Printf("⚠️ Failed to create indexes for %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// heatmapLevels is how many non-zero intensity levels a day can reach, like
+// the shades of a contribution graph.
+const heatmapLevels = 4
+
+type activityDay struct {
+	AchievedHours  float64 `bson:"achievedHours" json:"achievedHours"`
+	TargetHours    float64 `bson:"targetHours" json:"targetHours"`
+	CompletedItems int     `bson:"completedItems" json:"completedItems"`
+	ScheduledItems int     `bson:"scheduledItems" json:"scheduledItems"`
+	Checkmarks     int     `bson:"checkmarks" json:"checkmarks"`
+}
+
+// aggregateByDay runs a pipeline that groups into one document per day, keyed
+// by the YYYY-MM-DD date in _id, and merges each into days.
+func aggregateByDay(ctx context.Context, collection string, pipeline mongo.Pipeline, days map[string]*activityDay) error {
+	cursor, err := database.GetCollection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			Date   string      `bson:"_id"`
+			Totals activityDay `bson:",inline"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return err
+		}
+		day, ok := days[row.Date]
+		if !ok {
+			continue
+		}
+		day.AchievedHours += row.Totals.AchievedHours
+		day.TargetHours += row.Totals.TargetHours
+		day.CompletedItems += row.Totals.CompletedItems
+		day.ScheduledItems += row.Totals.ScheduledItems
+	}
+	return cursor.Err()
+}
+
+// GetActivityHeatmap returns every day of a year with an intensity level and
+// the working hours, schedule items and timetable checkmarks behind it. Each
+// source is scaled against its own busiest day, and a day's score is the
+// average over the sources the user actually uses.
+func GetActivityHeatmap(w http.ResponseWriter, r *http.Request) {
+	loc := userLocation(r)
+
+	year := time.Now().In(loc).Year()
+	if yearStr := r.URL.Query().Get("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil || parsed < 1970 || parsed > 9999 {
+			http.Error(w, "Invalid year", http.StatusBadRequest)
+			return
+		}
+		year = parsed
+	}
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	end := start.AddDate(1, 0, 0)
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	days := make(map[string]*activityDay)
+	var keys []string
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		key := d.Format(timeutil.DateLayout)
+		days[key] = &activityDay{}
+		keys = append(keys, key)
+	}
+
+	match := bson.M{"user": userObjID, "date": bson.M{"$gte": start, "$lt": end}}
+	dayKey := bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$date", "timezone": loc.String()}}
+
+	err := aggregateByDay(r.Context(), "workinghours", mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           dayKey,
+			"achievedHours": bson.M{"$sum": "$achievedHours"},
+			"targetHours":   bson.M{"$sum": "$targetHours"},
+		}}},
+	}, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = aggregateByDay(r.Context(), "schedules", mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": dayKey,
+			"completedItems": bson.M{"$sum": bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$items", bson.A{}}},
+				"cond":  "$$this.completed",
+			}}}},
+			"scheduledItems": bson.M{"$sum": bson.M{"$size": bson.M{"$ifNull": bson.A{"$items", bson.A{}}}}},
+		}}},
+	}, days)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Checkmarks live inside each timetable's weeks, where DailyStatus[i] is
+	// the i-th day counted from the week's Monday.
+	opts := options.Find().SetProjection(bson.M{"currentWeek": 1, "history": 1})
+	cursor, err := database.GetCollection("timetables").Find(r.Context(), bson.M{"user": userObjID}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var timetables []models.Timetable
+	if err = cursor.All(r.Context(), &timetables); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, timetable := range timetables {
+		for _, week := range append(timetable.History, timetable.CurrentWeek) {
+			if week.WeekStartDate.IsZero() {
+				continue
+			}
+			monday := timeutil.StartOfDay(week.WeekStartDate, loc)
+			for _, activity := range week.Activities {
+				for i, done := range activity.DailyStatus {
+					if !done {
+						continue
+					}
+					if day, ok := days[monday.AddDate(0, 0, i).Format(timeutil.DateLayout)]; ok {
+						day.Checkmarks++
+					}
+				}
+			}
+		}
+	}
+
+	var maxHours float64
+	var maxItems, maxCheckmarks int
+	totals := activityDay{}
+	for _, day := range days {
+		maxHours = math.Max(maxHours, day.AchievedHours)
+		if day.CompletedItems > maxItems {
+			maxItems = day.CompletedItems
+		}
+		if day.Checkmarks > maxCheckmarks {
+			maxCheckmarks = day.Checkmarks
+		}
+		totals.AchievedHours += day.AchievedHours
+		totals.TargetHours += day.TargetHours
+		totals.CompletedItems += day.CompletedItems
+		totals.ScheduledItems += day.ScheduledItems
+		totals.Checkmarks += day.Checkmarks
+	}
+
+	score := func(day *activityDay) float64 {
+		sum, sources := 0.0, 0
+		if maxHours > 0 {
+			sum += day.AchievedHours / maxHours
+			sources++
+		}
+		if maxItems > 0 {
+			sum += float64(day.CompletedItems) / float64(maxItems)
+			sources++
+		}
+		if maxCheckmarks > 0 {
+			sum += float64(day.Checkmarks) / float64(maxCheckmarks)
+			sources++
+		}
+		if sources == 0 {
+			return 0
+		}
+		return sum / float64(sources)
+	}
+
+	data := make([]map[string]interface{}, 0, len(keys))
+	activeDays := 0
+	for _, key := range keys {
+		day := days[key]
+		s := score(day)
+		level := int(math.Ceil(s * heatmapLevels))
+		if level > 0 {
+			activeDays++
+		}
+		data = append(data, map[string]interface{}{
+			"date":      key,
+			"score":     s,
+			"level":     level,
+			"breakdown": day,
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"year":    year,
+		"levels":  heatmapLevels,
+		"data":    data,
+		"summary": map[string]interface{}{
+			"activeDays": activeDays,
+			"totals":     totals,
+			"max": map[string]interface{}{
+				"achievedHours":  maxHours,
+				"completedItems": maxItems,
+				"checkmarks":     maxCheckmarks,
+			},
+		},
+	})
+}
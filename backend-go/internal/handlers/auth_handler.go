@@ -9,6 +9,8 @@ import (
 	"service-exchange-backend-go/internal/auth"
 	"service-exchange-backend-go/internal/database"
 	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -45,6 +47,7 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		Email       string `json:"email"`
 		PhoneNumber string `json:"phoneNumber"`
 		Password    string `json:"password"`
+		Timezone    string `json:"timezone"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -52,6 +55,11 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if input.Timezone != "" && !timeutil.IsValidTimezone(input.Timezone) {
+		http.Error(w, "Invalid timezone", http.StatusBadRequest)
+		return
+	}
+
 	collection := database.GetCollection("users")
 	var existingUser models.User
 	err := collection.FindOne(r.Context(), bson.M{"email": input.Email}).Decode(&existingUser)
@@ -77,6 +85,7 @@ func Register(w http.ResponseWriter, r *http.Request) {
 		Email:           input.Email,
 		PhoneNumber:     input.PhoneNumber,
 		Password:        hashedPassword,
+		Timezone:        input.Timezone,
 		CreatedAt:       time.Now(),
 		UpdatedAt:       time.Now(),
 		IsEmailVerified: false,
@@ -177,36 +186,51 @@ func Logout(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func UpdateDetails(w http.ResponseWriter, r *http.Request) {
-	var input struct {
-		Name        string `json:"name"`
-		PhoneNumber string `json:"phoneNumber"`
+func checkTimezone(value interface{}) string {
+	if !timeutil.IsValidTimezone(value.(string)) {
+		return "is not a valid IANA timezone"
 	}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	return ""
+}
+
+// userPatchSchema lists the fields UpdateDetails accepts. Removing the
+// timezone or conflict preference falls back to UTC and warnings.
+var userPatchSchema = patch.Schema{
+	"name":              {Kind: patch.String, Check: patch.NotBlank},
+	"phoneNumber":       {Kind: patch.String, Nullable: true, Check: patch.NotBlank},
+	"timezone":          {Kind: patch.String, Nullable: true, Check: checkTimezone},
+	"scheduleConflicts": {Kind: patch.String, Nullable: true, Check: patch.OneOf(models.ScheduleConflictsStrict, models.ScheduleConflictsWarn)},
+}
+
+func UpdateDetails(w http.ResponseWriter, r *http.Request) {
+	changes, err := patch.Decode(r.Body, userPatchSchema)
+	if err != nil {
+		writePatchError(w, err)
 		return
 	}
+	if name, ok := changes.Values["name"].(string); ok {
+		changes.Values["name"] = strings.TrimSpace(name)
+	}
 
 	userID := r.Context().Value(auth.UserContextKey).(string)
 	objID, _ := primitive.ObjectIDFromHex(userID)
 	collection := database.GetCollection("users")
 
-	update := bson.M{}
-	if input.Name != "" {
-		update["name"] = input.Name
-	}
-
-	if input.PhoneNumber != "" {
+	// A new or removed phone number has to be verified again.
+	extra := bson.M{}
+	if changes.Has("phoneNumber") {
 		var user models.User
 		collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&user)
-		if user.PhoneNumber != input.PhoneNumber {
-			update["phoneNumber"] = input.PhoneNumber
-			update["isPhoneVerified"] = false
+		if phone, _ := changes.Values["phoneNumber"].(string); phone == user.PhoneNumber {
+			delete(changes.Values, "phoneNumber")
+		} else {
+			extra["isPhoneVerified"] = false
 		}
 	}
 
-	if len(update) > 0 {
-		_, err := collection.UpdateOne(r.Context(), bson.M{"_id": objID}, bson.M{"$set": update})
+	if len(changes.Values) > 0 {
+		extra["updatedAt"] = time.Now()
+		_, err := collection.UpdateOne(r.Context(), bson.M{"_id": objID}, changes.Update(extra))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/ical"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/rrule"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The feed covers a window around today; calendar apps poll it, so older
+// and later days show up as the window moves.
+const (
+	feedPastDays   = 30
+	feedFutureDays = 90
+)
+
+const calendarProdID = "-//HustleX//Schedules//EN"
+
+const calendarFeedPath = "/api/calendar/feed/"
+
+// everyDay repeats a timetable activity on each day of the week.
+var everyDay = &rrule.Rule{
+	Freq:     rrule.Weekly,
+	Interval: 1,
+	ByDay: []rrule.WeekdayNum{
+		{Weekday: time.Monday}, {Weekday: time.Tuesday}, {Weekday: time.Wednesday},
+		{Weekday: time.Thursday}, {Weekday: time.Friday}, {Weekday: time.Saturday},
+		{Weekday: time.Sunday},
+	},
+	WeekStart: time.Monday,
+}
+
+// atClock returns minutes after midnight on day as a wall-clock time in
+// loc. Building the time from its fields keeps it right across DST changes.
+func atClock(day time.Time, minutes int, loc *time.Location) time.Time {
+	y, m, d := day.In(loc).Date()
+	return time.Date(y, m, d, 0, minutes, 0, 0, loc)
+}
+
+func scheduleItemEvents(schedules []models.Schedule, loc *time.Location) []ical.Event {
+	events := []ical.Event{}
+	for _, schedule := range schedules {
+		for _, item := range schedule.Items {
+			start, end, err := item.Window()
+			if err != nil {
+				continue
+			}
+			events = append(events, ical.Event{
+				UID:          "schedule-item-" + item.ID.Hex() + "@hustlex",
+				Summary:      item.Title,
+				Description:  item.Description,
+				Categories:   []string{item.Category},
+				Start:        atClock(schedule.Date, start, loc),
+				End:          atClock(schedule.Date, end, loc),
+				LastModified: schedule.UpdatedAt,
+			})
+		}
+	}
+	return events
+}
+
+// timetableEvents renders the timetable's default activities as daily
+// recurring events starting from the week the timetable was created.
+func timetableEvents(timetable *models.Timetable, loc *time.Location) []ical.Event {
+	events := []ical.Event{}
+	firstDay := timeutil.StartOfWeek(timetable.CreatedAt, loc)
+	for _, activity := range timetable.DefaultActivities {
+		startStr, endStr, ok := strings.Cut(activity.Time, "-")
+		if !ok {
+			continue
+		}
+		start, ok := models.ClockMinutes(startStr)
+		if !ok {
+			continue
+		}
+		end, ok := models.ClockMinutes(endStr)
+		if !ok || end == start {
+			continue
+		}
+		// Activities such as "18:00-00:00" run into the next day.
+		if end < start {
+			end += models.MinutesPerDay
+		}
+		var categories []string
+		if activity.Category != "" {
+			categories = []string{activity.Category}
+		}
+		events = append(events, ical.Event{
+			UID:          "timetable-activity-" + activity.ID.Hex() + "@hustlex",
+			Summary:      activity.Name,
+			Description:  timetable.Name,
+			Categories:   categories,
+			Start:        atClock(firstDay, start, loc),
+			End:          atClock(firstDay, end, loc),
+			RRule:        everyDay.String(),
+			LastModified: timetable.UpdatedAt,
+		})
+	}
+	return events
+}
+
+// ensureActivityIDs gives activities stored before they had IDs one, and
+// saves them, so their feed UIDs stay the same from one poll to the next.
+func ensureActivityIDs(ctx context.Context, timetable *models.Timetable) {
+	missing := false
+	for _, activity := range timetable.DefaultActivities {
+		if activity.ID.IsZero() {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return
+	}
+	assignActivityIDs(timetable.DefaultActivities, nil)
+	_, err := database.GetCollection("timetables").UpdateOne(ctx,
+		bson.M{"_id": timetable.ID},
+		bson.M{"$set": bson.M{"defaultActivities": timetable.DefaultActivities}},
+	)
+	if err != nil {
+		log.Printf("⚠️ Failed to save activity IDs for timetable %s: %v", timetable.ID.Hex(), err)
+	}
+}
+
+// CreateCalendarFeedToken issues a new feed URL for the user, replacing any
+// earlier one. The token is shown only in this response.
+func CreateCalendarFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	token, hash, err := auth.NewFeedToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, err = database.GetCollection("users").UpdateOne(r.Context(),
+		bson.M{"_id": userObjID},
+		bson.M{"$set": bson.M{"calendarFeedTokenHash": hash}},
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	path := calendarFeedPath + token + ".ics"
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"token":     token,
+			"url":       scheme + "://" + r.Host + path,
+			"webcalUrl": "webcal://" + r.Host + path,
+		},
+	})
+}
+
+// RevokeCalendarFeedToken disables the user's feed URL.
+func RevokeCalendarFeedToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	_, err := database.GetCollection("users").UpdateOne(r.Context(),
+		bson.M{"_id": userObjID},
+		bson.M{"$unset": bson.M{"calendarFeedTokenHash": ""}},
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Calendar feed disabled",
+	})
+}
+
+// GetCalendarFeed serves /api/calendar/feed/:token.ics. Calendar apps cannot
+// send a bearer token, so the token in the URL is the credential.
+func GetCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, calendarFeedPath), ".ics")
+	if token == "" || strings.Contains(token, "/") {
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"name": 1, "timezone": 1})
+	err := database.GetCollection("users").FindOne(r.Context(), bson.M{"calendarFeedTokenHash": auth.HashFeedToken(token)}, opts).Decode(&user)
+	if err != nil {
+		http.Error(w, "Calendar feed not found", http.StatusNotFound)
+		return
+	}
+	loc := timeutil.Location(user.Timezone)
+
+	today := timeutil.StartOfDay(time.Now(), loc)
+	from, to := today.AddDate(0, 0, -feedPastDays), today.AddDate(0, 0, feedFutureDays)
+	materializeScheduleRange(r.Context(), user.ID, from, to, loc)
+
+	findOpts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := database.GetCollection("schedules").Find(r.Context(), bson.M{
+		"user": user.ID,
+		"date": bson.M{"$gte": from, "$lt": to.AddDate(0, 0, 1)},
+	}, findOpts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var schedules []models.Schedule
+	if err = cursor.All(r.Context(), &schedules); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events := scheduleItemEvents(schedules, loc)
+	var timetable models.Timetable
+	err = database.GetCollection("timetables").FindOne(r.Context(), bson.M{"user": user.ID, "isActive": true}).Decode(&timetable)
+	if err == nil {
+		ensureActivityIDs(r.Context(), &timetable)
+		events = append(events, timetableEvents(&timetable, loc)...)
+	}
+
+	calendar := ical.Calendar{
+		ProdID:   calendarProdID,
+		Name:     "HustleX",
+		Location: loc,
+		Events:   events,
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="hustlex.ics"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := calendar.Encode(w); err != nil {
+		log.Printf("⚠️ Failed to write calendar feed: %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"service-exchange-backend-go/internal/auth"
 	"service-exchange-backend-go/internal/database"
 	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -112,6 +113,15 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// categoryPatchSchema lists the fields UpdateCategory accepts. A category
+// keeps its type; only the description may be cleared.
+var categoryPatchSchema = patch.Schema{
+	"name":        {Kind: patch.String, Check: patch.NotBlank},
+	"color":       {Kind: patch.String},
+	"icon":        {Kind: patch.String},
+	"description": {Kind: patch.String, Nullable: true},
+}
+
 func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	id := parts[len(parts)-1]
@@ -121,14 +131,9 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input struct {
-		Name        string `json:"name"`
-		Color       string `json:"color"`
-		Icon        string `json:"icon"`
-		Description string `json:"description"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	changes, err := patch.Decode(r.Body, categoryPatchSchema)
+	if err != nil {
+		writePatchError(w, err)
 		return
 	}
 
@@ -143,47 +148,40 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	update := bson.M{"updatedAt": time.Now()}
-
-	if input.Name != "" && input.Name != category.Name {
-		count, _ := collection.CountDocuments(r.Context(), bson.M{
-			"user": userObjID,
-			"name": strings.TrimSpace(input.Name),
-			"type": category.Type,
-			"_id":  bson.M{"$ne": category.ID},
-		})
-		if count > 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"message": "Category with this name already exists",
+	if name, ok := changes.Values["name"].(string); ok {
+		name = strings.TrimSpace(name)
+		changes.Values["name"] = name
+		if name != category.Name {
+			count, _ := collection.CountDocuments(r.Context(), bson.M{
+				"user": userObjID,
+				"name": name,
+				"type": category.Type,
+				"_id":  bson.M{"$ne": category.ID},
 			})
-			return
+			if count > 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success": false,
+					"message": "Category with this name already exists",
+				})
+				return
+			}
 		}
-		update["name"] = strings.TrimSpace(input.Name)
 	}
 
-	if input.Color != "" {
-		update["color"] = input.Color
-	}
-	if input.Icon != "" {
-		update["icon"] = input.Icon
-	}
-	if input.Description != "" {
-		update["description"] = input.Description
-	}
-
-	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID}, bson.M{"$set": update})
+	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID}, changes.Update(bson.M{"updatedAt": time.Now()}))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&category)
+	// Decode into a fresh value so cleared fields don't keep their old ones.
+	var updated models.Category
+	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&updated)
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"data":    category,
+		"data":    updated,
 	})
 }
 
@@ -0,0 +1,532 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// findFocusSettings returns the user's Pomodoro lengths, or the defaults when
+// they never changed them.
+func findFocusSettings(ctx context.Context, userObjID primitive.ObjectID) (models.FocusSettings, error) {
+	settings := models.DefaultFocusSettings
+	err := database.GetCollection("focussettings").FindOne(ctx, bson.M{"user": userObjID}).Decode(&settings)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return settings, err
+	}
+	settings.User = userObjID
+	return settings, nil
+}
+
+func GetFocusSettings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	settings, err := findFocusSettings(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// focusSettingsPatchSchema lists the lengths UpdateFocusSettings accepts.
+var focusSettingsPatchSchema = patch.Schema{
+	"workMinutes":       {Kind: patch.Integer, Check: patch.Between(1, 180)},
+	"shortBreakMinutes": {Kind: patch.Integer, Check: patch.Between(1, 60)},
+	"longBreakMinutes":  {Kind: patch.Integer, Check: patch.Between(1, 120)},
+	"longBreakEvery":    {Kind: patch.Integer, Check: patch.Between(1, 12)},
+}
+
+func UpdateFocusSettings(w http.ResponseWriter, r *http.Request) {
+	changes, err := patch.Decode(r.Body, focusSettingsPatchSchema)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	settings, err := findFocusSettings(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := changes.Apply(&settings); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	settings.UpdatedAt = time.Now()
+
+	_, err = database.GetCollection("focussettings").UpdateOne(r.Context(),
+		bson.M{"user": userObjID},
+		bson.M{"$set": bson.M{
+			"workMinutes":       settings.WorkMinutes,
+			"shortBreakMinutes": settings.ShortBreakMinutes,
+			"longBreakMinutes":  settings.LongBreakMinutes,
+			"longBreakEvery":    settings.LongBreakEvery,
+			"updatedAt":         settings.UpdatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// StartFocusSession starts a work interval. Sessions linked to a schedule item
+// or skill take its category; otherwise the category must be given. Only one
+// session can run at a time.
+func StartFocusSession(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ScheduleID     string `json:"scheduleId"`
+		ScheduleItemID string `json:"scheduleItemId"`
+		SkillID        string `json:"skillId"`
+		Category       string `json:"category"`
+		WorkMinutes    int    `json:"workMinutes"`
+		BreakMinutes   int    `json:"breakMinutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	settings, err := findFocusSettings(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if input.WorkMinutes == 0 {
+		input.WorkMinutes = settings.WorkMinutes
+	}
+	if input.BreakMinutes == 0 {
+		input.BreakMinutes = settings.ShortBreakMinutes
+	}
+	if input.WorkMinutes < 1 || input.WorkMinutes > 180 {
+		http.Error(w, "Work minutes must be between 1 and 180", http.StatusBadRequest)
+		return
+	}
+	if input.BreakMinutes < 1 || input.BreakMinutes > 60 {
+		http.Error(w, "Break minutes must be between 1 and 60", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	session := models.FocusSession{
+		ID:           primitive.NewObjectID(),
+		User:         userObjID,
+		Date:         timeutil.StartOfDay(now, userLocation(r)),
+		Category:     strings.TrimSpace(input.Category),
+		Status:       models.FocusSessionActive,
+		WorkMinutes:  input.WorkMinutes,
+		BreakMinutes: input.BreakMinutes,
+		StartedAt:    now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if input.SkillID != "" {
+		skillObjID, err := primitive.ObjectIDFromHex(input.SkillID)
+		if err != nil {
+			http.Error(w, "Invalid skill ID", http.StatusBadRequest)
+			return
+		}
+		var skill models.Skill
+		err = database.GetCollection("skills").FindOne(r.Context(), bson.M{"_id": skillObjID, "user": userObjID}).Decode(&skill)
+		if err != nil {
+			http.Error(w, "Skill not found", http.StatusNotFound)
+			return
+		}
+		session.SkillID = &skill.ID
+		session.Category = skill.Category
+	}
+
+	if input.ScheduleItemID != "" {
+		scheduleObjID, err := primitive.ObjectIDFromHex(input.ScheduleID)
+		if err != nil {
+			http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+			return
+		}
+		itemObjID, err := primitive.ObjectIDFromHex(input.ScheduleItemID)
+		if err != nil {
+			http.Error(w, "Invalid schedule item ID", http.StatusBadRequest)
+			return
+		}
+		var schedule models.Schedule
+		err = database.GetCollection("schedules").FindOne(r.Context(), bson.M{"_id": scheduleObjID, "user": userObjID}).Decode(&schedule)
+		if err != nil {
+			http.Error(w, "Schedule not found", http.StatusNotFound)
+			return
+		}
+		found := false
+		for _, item := range schedule.Items {
+			if item.ID == itemObjID {
+				session.ScheduleID = &schedule.ID
+				session.ScheduleItemID = &itemObjID
+				session.Category = item.Category
+				found = true
+				break
+			}
+		}
+		if !found {
+			http.Error(w, "Item not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	if session.Category == "" {
+		http.Error(w, "Category is required when the session is not linked to a schedule item or skill", http.StatusBadRequest)
+		return
+	}
+
+	_, err = database.GetCollection("focussessions").InsertOne(r.Context(), session)
+	if mongo.IsDuplicateKeyError(err) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "A focus session is already running",
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    session,
+		"endsAt":  session.StartedAt.Add(time.Duration(session.WorkMinutes) * time.Minute),
+	})
+}
+
+// addFocusTimeToWorkingHours adds a session's focused minutes to the day's
+// working hours entry, creating the entry in the session's category when
+// there is none yet. The session is recorded on the entry, so adding the
+// same session again changes nothing.
+func addFocusTimeToWorkingHours(ctx context.Context, session models.FocusSession) error {
+	template, err := findWorkingHoursTemplate(ctx, session.User)
+	if err != nil {
+		return err
+	}
+
+	collection := database.GetCollection("workinghours")
+	now := time.Now()
+	filter := workingHoursDayQuery(session.User, session.Date)
+	_, err = collection.UpdateOne(ctx, filter,
+		bson.M{"$setOnInsert": bson.M{
+			"date":          session.Date,
+			"category":      session.Category,
+			"targetHours":   templateTarget(template, session.Date, session.Category),
+			"achievedHours": 0.0,
+			"mood":          models.MoodNormal,
+			"createdAt":     now,
+			"updatedAt":     now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	filter["focusSessionIds"] = bson.M{"$ne": session.ID}
+	_, err = collection.UpdateOne(ctx, filter, bson.M{
+		"$inc":  bson.M{"achievedHours": session.FocusedMinutes / 60},
+		"$push": bson.M{"focusSessionIds": session.ID},
+		"$set":  bson.M{"updatedAt": now},
+	})
+	return err
+}
+
+// endFocusSession closes the active session with the given status. Focused
+// time is capped at the planned work length, and only completed sessions
+// count towards working hours.
+func endFocusSession(w http.ResponseWriter, r *http.Request, status models.FocusSessionStatus, set bson.M) {
+	parts := strings.Split(r.URL.Path, "/")
+	// .../focus/sessions/:id/complete
+	id := parts[len(parts)-2]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("focussessions")
+
+	var session models.FocusSession
+	if err := collection.FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&session); err != nil {
+		http.Error(w, "Focus session not found", http.StatusNotFound)
+		return
+	}
+
+	if session.Status != models.FocusSessionActive {
+		http.Error(w, "Focus session has already ended", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	focused := math.Min(now.Sub(session.StartedAt).Minutes(), float64(session.WorkMinutes))
+	set["status"] = status
+	set["endedAt"] = now
+	set["focusedMinutes"] = focused
+	set["updatedAt"] = now
+
+	// The time is logged before the session is closed, so a failure leaves
+	// it active to be completed again rather than ended and never counted.
+	if status == models.FocusSessionCompleted {
+		logged := session
+		logged.FocusedMinutes = focused
+		if err := addFocusTimeToWorkingHours(r.Context(), logged); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Matching on the active status makes ending a session happen once, even
+	// when the client retries.
+	res, err := collection.UpdateOne(r.Context(),
+		bson.M{"_id": objID, "user": userObjID, "status": models.FocusSessionActive},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if res.MatchedCount == 0 {
+		http.Error(w, "Focus session has already ended", http.StatusBadRequest)
+		return
+	}
+	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&session)
+	if session.SkillID != nil && session.FocusedMinutes > 0 {
+		startPracticedSkills(r.Context(), userObjID, []primitive.ObjectID{*session.SkillID})
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"data":    session,
+	}
+
+	if status == models.FocusSessionCompleted {
+		settings, err := findFocusSettings(r.Context(), userObjID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		completedToday, _ := collection.CountDocuments(r.Context(), bson.M{
+			"user":   userObjID,
+			"date":   session.Date,
+			"status": models.FocusSessionCompleted,
+		})
+		breakMinutes := session.BreakMinutes
+		if completedToday%int64(settings.LongBreakEvery) == 0 {
+			breakMinutes = settings.LongBreakMinutes
+		}
+		response["completedToday"] = completedToday
+		response["breakMinutes"] = breakMinutes
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+func CompleteFocusSession(w http.ResponseWriter, r *http.Request) {
+	endFocusSession(w, r, models.FocusSessionCompleted, bson.M{})
+}
+
+func InterruptFocusSession(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Reason models.InterruptionReason `json:"reason"`
+		Note   string                    `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !input.Reason.IsValid() {
+		http.Error(w, "Reason must be one of distracted, external, meeting, urgent, tired or other", http.StatusBadRequest)
+		return
+	}
+
+	set := bson.M{"interruptionReason": input.Reason}
+	if note := strings.TrimSpace(input.Note); note != "" {
+		set["interruptionNote"] = note
+	}
+	endFocusSession(w, r, models.FocusSessionInterrupted, set)
+}
+
+func GetActiveFocusSession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var session *models.FocusSession
+	err := database.GetCollection("focussessions").FindOne(r.Context(), bson.M{
+		"user":   userObjID,
+		"status": models.FocusSessionActive,
+	}).Decode(&session)
+	if err != nil && err != mongo.ErrNoDocuments {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    session,
+	})
+}
+
+func GetFocusSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	query := bson.M{"user": userObjID}
+	if dateQuery := dateRangeQuery(r.URL.Query().Get("startDate"), r.URL.Query().Get("endDate"), userLocation(r)); dateQuery != nil {
+		query["date"] = dateQuery
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query["status"] = status
+	}
+
+	opts := options.Find().SetSort(bson.M{"startedAt": -1})
+	cursor, err := database.GetCollection("focussessions").Find(r.Context(), query, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(r.Context())
+
+	sessions := []models.FocusSession{}
+	if err = cursor.All(r.Context(), &sessions); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(sessions),
+		"data":    sessions,
+	})
+}
+
+// GetFocusStats counts sessions per day and totals interruption reasons over
+// the requested range.
+func GetFocusStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	loc := userLocation(r)
+	match := bson.M{"user": userObjID, "status": bson.M{"$ne": models.FocusSessionActive}}
+	if dateQuery := dateRangeQuery(r.URL.Query().Get("startDate"), r.URL.Query().Get("endDate"), loc); dateQuery != nil {
+		match["date"] = dateQuery
+	}
+
+	countIf := func(status models.FocusSessionStatus) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", status}}, 1, 0}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: bson.M{
+			"byDay": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":            bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$date", "timezone": loc.String()}},
+					"completed":      countIf(models.FocusSessionCompleted),
+					"interrupted":    countIf(models.FocusSessionInterrupted),
+					"focusedMinutes": bson.M{"$sum": "$focusedMinutes"},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"reasons": bson.A{
+				bson.M{"$match": bson.M{"status": models.FocusSessionInterrupted}},
+				bson.M{"$group": bson.M{"_id": "$interruptionReason", "count": bson.M{"$sum": 1}}},
+			},
+		}}},
+	}
+
+	cursor, err := database.GetCollection("focussessions").Aggregate(r.Context(), pipeline)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(r.Context())
+
+	var results []struct {
+		ByDay []struct {
+			Date           string  `bson:"_id" json:"date"`
+			Completed      int     `bson:"completed" json:"completed"`
+			Interrupted    int     `bson:"interrupted" json:"interrupted"`
+			FocusedMinutes float64 `bson:"focusedMinutes" json:"focusedMinutes"`
+		} `bson:"byDay"`
+		Reasons []struct {
+			Reason string `bson:"_id"`
+			Count  int    `bson:"count"`
+		} `bson:"reasons"`
+	}
+	if err = cursor.All(r.Context(), &results); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var byDay interface{} = []interface{}{}
+	reasons := make(map[string]int)
+	completed, interrupted := 0, 0
+	focusedMinutes := 0.0
+	if len(results) > 0 {
+		for _, day := range results[0].ByDay {
+			completed += day.Completed
+			interrupted += day.Interrupted
+			focusedMinutes += day.FocusedMinutes
+		}
+		if results[0].ByDay != nil {
+			byDay = results[0].ByDay
+		}
+		for _, reason := range results[0].Reasons {
+			reasons[reason.Reason] = reason.Count
+		}
+	}
+
+	completionRate := 0.0
+	if completed+interrupted > 0 {
+		completionRate = float64(completed) / float64(completed+interrupted) * 100
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"completed":      completed,
+			"interrupted":    interrupted,
+			"completionRate": completionRate,
+			"focusedMinutes": focusedMinutes,
+			"byDay":          byDay,
+			"reasons":        reasons,
+		},
+	})
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// itemTimesMessage turns an item window error into a client message.
+func itemTimesMessage(err error) string {
+	msg := err.Error()
+	return strings.ToUpper(msg[:1]) + msg[1:]
+}
+
+// checkItemTimes validates the times of every item, naming the first bad one.
+func checkItemTimes(items []models.ScheduleItem) string {
+	for _, item := range items {
+		if _, _, err := item.Window(); err != nil {
+			msg := itemTimesMessage(err)
+			if item.Title != "" {
+				return msg + " (" + item.Title + ")"
+			}
+			return msg
+		}
+	}
+	return ""
+}
+
+type conflictEntry struct {
+	ID        *primitive.ObjectID `json:"id,omitempty"`
+	Title     string              `json:"title"`
+	StartTime string              `json:"startTime"`
+	EndTime   string              `json:"endTime"`
+}
+
+// scheduleConflict is a pair of overlapping entries. With is another item on
+// the same schedule, or an activity of the active timetable when Source is
+// "timetable".
+type scheduleConflict struct {
+	Date   string        `json:"date,omitempty"`
+	Source string        `json:"source"`
+	Item   conflictEntry `json:"item"`
+	With   conflictEntry `json:"with"`
+}
+
+func itemEntry(item models.ScheduleItem) conflictEntry {
+	id := item.ID
+	return conflictEntry{ID: &id, Title: item.Title, StartTime: item.StartTime, EndTime: item.EndTime}
+}
+
+// findItemOverlaps lists each pair of items whose windows overlap.
+func findItemOverlaps(items []models.ScheduleItem) []scheduleConflict {
+	conflicts := []scheduleConflict{}
+	for _, overlap := range models.FindOverlaps(items) {
+		conflicts = append(conflicts, scheduleConflict{
+			Source: "schedule",
+			Item:   itemEntry(overlap.Item),
+			With:   itemEntry(overlap.With),
+		})
+	}
+	return conflicts
+}
+
+// activityWindows reads a timetable activity's "HH:MM-HH:MM" time. An
+// activity that runs past midnight covers the end of the day and its start.
+func activityWindows(activity models.Activity) [][2]int {
+	startStr, endStr, ok := strings.Cut(activity.Time, "-")
+	if !ok {
+		return nil
+	}
+	start, ok := models.ClockMinutes(startStr)
+	if !ok {
+		return nil
+	}
+	end, ok := models.ClockMinutes(endStr)
+	if !ok || end == start {
+		return nil
+	}
+	if end < start {
+		return [][2]int{{start, models.MinutesPerDay}, {0, end}}
+	}
+	return [][2]int{{start, end}}
+}
+
+// findActivityOverlaps lists items that overlap a timetable activity. The
+// timetable repeats its default activities every day of the week.
+func findActivityOverlaps(items []models.ScheduleItem, activities []models.Activity) []scheduleConflict {
+	conflicts := []scheduleConflict{}
+	for _, item := range items {
+		start, end, err := item.Window()
+		if err != nil {
+			continue
+		}
+		for _, activity := range activities {
+			for _, window := range activityWindows(activity) {
+				if start < window[1] && window[0] < end {
+					startTime, endTime, _ := strings.Cut(activity.Time, "-")
+					conflicts = append(conflicts, scheduleConflict{
+						Source: "timetable",
+						Item:   itemEntry(item),
+						With: conflictEntry{
+							Title:     activity.Name,
+							StartTime: strings.TrimSpace(startTime),
+							EndTime:   strings.TrimSpace(endTime),
+						},
+					})
+					break
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// userConflictMode returns the authenticated user's overlap preference.
+func userConflictMode(r *http.Request) string {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"scheduleConflicts": 1})
+	if err := database.GetCollection("users").FindOne(r.Context(), bson.M{"_id": userObjID}, opts).Decode(&user); err != nil {
+		return models.ScheduleConflictsWarn
+	}
+	if user.ScheduleConflicts == models.ScheduleConflictsStrict {
+		return models.ScheduleConflictsStrict
+	}
+	return models.ScheduleConflictsWarn
+}
+
+// changedItemIDs lists the items of after that are new or have new times
+// compared with before.
+func changedItemIDs(before, after []models.ScheduleItem) []primitive.ObjectID {
+	previous := make(map[primitive.ObjectID]models.ScheduleItem, len(before))
+	for _, item := range before {
+		previous[item.ID] = item
+	}
+	changed := []primitive.ObjectID{}
+	for _, item := range after {
+		old, ok := previous[item.ID]
+		if !ok || old.StartTime != item.StartTime || old.EndTime != item.EndTime {
+			changed = append(changed, item.ID)
+		}
+	}
+	return changed
+}
+
+// overlapsInvolving keeps the conflicts in which one of the given items
+// takes part.
+func overlapsInvolving(conflicts []scheduleConflict, ids []primitive.ObjectID) []scheduleConflict {
+	wanted := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	involved := []scheduleConflict{}
+	for _, c := range conflicts {
+		if (c.Item.ID != nil && wanted[*c.Item.ID]) || (c.With.ID != nil && wanted[*c.With.ID]) {
+			involved = append(involved, c)
+		}
+	}
+	return involved
+}
+
+// checkScheduleOverlaps looks for overlapping items before a schedule is
+// saved. In strict mode it writes a 409 and reports false when one of the
+// changed items overlaps another; overlaps the schedule already had do not
+// block the save. Otherwise every overlap is returned so the response can
+// warn about them.
+func checkScheduleOverlaps(w http.ResponseWriter, r *http.Request, items []models.ScheduleItem, changed []primitive.ObjectID) ([]scheduleConflict, bool) {
+	conflicts := findItemOverlaps(items)
+	if writeOverlapConflicts(w, r, overlapsInvolving(conflicts, changed)) {
+		return nil, false
+	}
+	return conflicts, true
+}
+
+// writeOverlapConflicts answers 409 when a strict user's schedules would end
+// up with overlapping items, and reports whether it did.
+func writeOverlapConflicts(w http.ResponseWriter, r *http.Request, conflicts []scheduleConflict) bool {
+	if len(conflicts) == 0 || userConflictMode(r) != models.ScheduleConflictsStrict {
+		return false
+	}
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":   false,
+		"message":   "Schedule items overlap",
+		"conflicts": conflicts,
+	})
+	return true
+}
+
+func writeItemTimesError(w http.ResponseWriter, msg string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"message": msg,
+	})
+}
+
+// GetScheduleConflicts lists overlapping items on every schedule between
+// startDate and endDate, along with items that clash with the active
+// timetable's activities.
+func GetScheduleConflicts(w http.ResponseWriter, r *http.Request) {
+	startDateStr := r.URL.Query().Get("startDate")
+	endDateStr := r.URL.Query().Get("endDate")
+
+	loc := userLocation(r)
+	dateQuery := dateRangeQuery(startDateStr, endDateStr, loc)
+	if dateQuery == nil {
+		http.Error(w, "startDate and endDate are required", http.StatusBadRequest)
+		return
+	}
+	from, until := dateQuery["$gte"].(time.Time), dateQuery["$lt"].(time.Time)
+	if daysBetween(from, until) > maxPrefillDays {
+		http.Error(w, "Date range is too long", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	materializeScheduleRange(r.Context(), userObjID, from, until.AddDate(0, 0, -1), loc)
+
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := database.GetCollection("schedules").Find(r.Context(), bson.M{"user": userObjID, "date": dateQuery}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var schedules []models.Schedule
+	if err = cursor.All(r.Context(), &schedules); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var activities []models.Activity
+	var timetable models.Timetable
+	err = database.GetCollection("timetables").FindOne(r.Context(), bson.M{"user": userObjID, "isActive": true}).Decode(&timetable)
+	if err == nil {
+		activities = timetable.DefaultActivities
+	}
+
+	conflicts := []scheduleConflict{}
+	for _, schedule := range schedules {
+		day := append(findItemOverlaps(schedule.Items), findActivityOverlaps(schedule.Items, activities)...)
+		date := schedule.Date.In(loc).Format(timeutil.DateLayout)
+		for i := range day {
+			day[i].Date = date
+		}
+		conflicts = append(conflicts, day...)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(conflicts),
+		"data":    conflicts,
+	})
+}
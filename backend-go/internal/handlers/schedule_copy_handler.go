@@ -0,0 +1,498 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// What DuplicateSchedule does with a target date that already has a
+// schedule: refuse the whole request, as CreateSchedule would, leave that
+// date out, or add the copied items to the existing schedule.
+const (
+	onExistingReject = "reject"
+	onExistingSkip   = "skip"
+	onExistingMerge  = "merge"
+)
+
+// cloneItems copies items as fresh, not yet completed ones, moved shift
+// minutes later (earlier when negative). Items must stay within their day.
+func cloneItems(items []models.ScheduleItem, shift int) ([]models.ScheduleItem, error) {
+	clones := make([]models.ScheduleItem, 0, len(items))
+	for _, item := range items {
+		clone := item
+		clone.ID = primitive.NewObjectID()
+		clone.Completed = false
+		clone.ImportUID = ""
+		clone.Origin = nil
+		if shift != 0 {
+			start, end, err := item.Window()
+			if err != nil {
+				return nil, fmt.Errorf("%s cannot be shifted: %v", item.Title, err)
+			}
+			start, end = start+shift, end+shift
+			if start < 0 || end > models.MinutesPerDay {
+				return nil, fmt.Errorf("shifting by %d minutes moves %s out of the day", shift, item.Title)
+			}
+			clone.StartTime = fmt.Sprintf("%02d:%02d", start/60, start%60)
+			clone.EndTime = fmt.Sprintf("%02d:%02d", end/60, end%60)
+		}
+		clones = append(clones, clone)
+	}
+	return clones, nil
+}
+
+// findSchedulesOn returns the user's schedules on the given days, keyed by
+// date.
+func findSchedulesOn(ctx context.Context, userObjID primitive.ObjectID, days []time.Time, loc *time.Location) (map[string]*models.Schedule, error) {
+	cursor, err := database.GetCollection("schedules").Find(ctx, bson.M{
+		"user": userObjID,
+		"date": bson.M{"$in": days},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var schedules []models.Schedule
+	if err = cursor.All(ctx, &schedules); err != nil {
+		return nil, err
+	}
+	byDay := make(map[string]*models.Schedule, len(schedules))
+	for i := range schedules {
+		byDay[schedules[i].Date.In(loc).Format(timeutil.DateLayout)] = &schedules[i]
+	}
+	return byDay, nil
+}
+
+func newScheduleOn(userObjID primitive.ObjectID, day time.Time, items []models.ScheduleItem, now time.Time) *models.Schedule {
+	schedule := &models.Schedule{
+		ID:        primitive.NewObjectID(),
+		User:      userObjID,
+		Date:      day,
+		DayType:   models.ScheduleDayType(day),
+		Items:     items,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	schedule.CalculateStats()
+	return schedule
+}
+
+func datedOverlaps(items []models.ScheduleItem, date string) []scheduleConflict {
+	conflicts := findItemOverlaps(items)
+	for i := range conflicts {
+		conflicts[i].Date = date
+	}
+	return conflicts
+}
+
+// writeScheduleCreatedMeanwhile answers when another request created a
+// schedule on a target date after it was looked up.
+func writeScheduleCreatedMeanwhile(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"message": "A schedule was created on a target date meanwhile, try again",
+	})
+}
+
+// writeScheduleChangedMeanwhile answers when another request changed a
+// schedule after it was read, so writing it back would lose that change.
+func writeScheduleChangedMeanwhile(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"message": "The schedule was changed by another request meanwhile, try again",
+	})
+}
+
+// saveScheduleIfUnchanged writes schedule back whole, stamping updatedAt, but
+// only while the stored updatedAt is still read. It reports false when
+// another request changed the schedule in between.
+func saveScheduleIfUnchanged(ctx context.Context, schedule *models.Schedule, read time.Time) (bool, error) {
+	filter := bson.M{"_id": schedule.ID, "updatedAt": read}
+	if read.IsZero() {
+		filter["updatedAt"] = bson.M{"$in": bson.A{nil, time.Time{}}}
+	}
+	schedule.UpdatedAt = time.Now()
+	res, err := database.GetCollection("schedules").UpdateOne(ctx, filter, bson.M{"$set": schedule})
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// DuplicateSchedule copies a schedule's items to other dates, optionally
+// shifted by shiftMinutes. Dates keep one schedule each: onExisting says
+// what happens where one already exists.
+func DuplicateSchedule(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) < 2 {
+		http.Error(w, "Invalid URL", http.StatusBadRequest)
+		return
+	}
+	objID, err := primitive.ObjectIDFromHex(parts[len(parts)-2])
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Dates        []string `json:"dates"`
+		ShiftMinutes int      `json:"shiftMinutes"`
+		OnExisting   string   `json:"onExisting"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.OnExisting == "" {
+		input.OnExisting = onExistingReject
+	}
+	if input.OnExisting != onExistingReject && input.OnExisting != onExistingSkip && input.OnExisting != onExistingMerge {
+		http.Error(w, "onExisting must be reject, skip or merge", http.StatusBadRequest)
+		return
+	}
+	if len(input.Dates) == 0 {
+		http.Error(w, "At least one target date is required", http.StatusBadRequest)
+		return
+	}
+	if len(input.Dates) > maxPrefillDays {
+		http.Error(w, fmt.Sprintf("At most %d dates can be copied to at once", maxPrefillDays), http.StatusBadRequest)
+		return
+	}
+
+	loc := userLocation(r)
+	seen := map[string]bool{}
+	var days []time.Time
+	for _, value := range input.Dates {
+		date, err := timeutil.ParseDate(value, loc)
+		if err != nil {
+			http.Error(w, "Invalid date "+value, http.StatusBadRequest)
+			return
+		}
+		day := timeutil.StartOfDay(date, loc)
+		if key := day.Format(timeutil.DateLayout); !seen[key] {
+			seen[key] = true
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("schedules")
+
+	var source models.Schedule
+	err = collection.FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&source)
+	if err != nil {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return
+	}
+	if _, err := cloneItems(source.Items, input.ShiftMinutes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Template occurrences on the targets count as existing schedules.
+	materializeScheduleRange(r.Context(), userObjID, days[0], days[len(days)-1], loc)
+	existing, err := findSchedulesOn(r.Context(), userObjID, days, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if input.OnExisting == onExistingReject && len(existing) > 0 {
+		taken := []string{}
+		for _, day := range days {
+			if key := day.Format(timeutil.DateLayout); existing[key] != nil {
+				taken = append(taken, key)
+			}
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Schedule already exists for these dates",
+			"dates":   taken,
+		})
+		return
+	}
+
+	now := time.Now()
+	created := []*models.Schedule{}
+	updated := []*models.Schedule{}
+	skipped := []string{}
+	conflicts := []scheduleConflict{}
+	blocking := []scheduleConflict{}
+	for _, day := range days {
+		key := day.Format(timeutil.DateLayout)
+		items, _ := cloneItems(source.Items, input.ShiftMinutes)
+		target := existing[key]
+		switch {
+		case target == nil:
+			target = newScheduleOn(userObjID, day, items, now)
+			created = append(created, target)
+		case input.OnExisting == onExistingSkip:
+			skipped = append(skipped, key)
+			continue
+		default:
+			target.Items = append(target.Items, items...)
+			target.CalculateStats()
+			markOccurrenceEdited(target)
+			target.UpdatedAt = now
+			updated = append(updated, target)
+		}
+		// Only overlaps involving the copies stop a strict user.
+		day := datedOverlaps(target.Items, key)
+		conflicts = append(conflicts, day...)
+		blocking = append(blocking, overlapsInvolving(day, changedItemIDs(nil, items))...)
+	}
+	if writeOverlapConflicts(w, r, blocking) {
+		return
+	}
+
+	if len(created) > 0 {
+		docs := make([]interface{}, len(created))
+		for i, s := range created {
+			docs[i] = s
+		}
+		_, err := collection.InsertMany(r.Context(), docs)
+		if mongo.IsDuplicateKeyError(err) {
+			writeScheduleCreatedMeanwhile(w)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if len(updated) > 0 {
+		writes := make([]mongo.WriteModel, len(updated))
+		for i, s := range updated {
+			writes[i] = mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": s.ID}).
+				SetUpdate(bson.M{"$set": s})
+		}
+		if _, err := collection.BulkWrite(r.Context(), writes); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"created": created,
+			"updated": updated,
+			"skipped": skipped,
+		},
+		"conflicts": conflicts,
+	})
+}
+
+// RolloverSchedule carries the unfinished items of the schedule on date over
+// to toDate, the next day unless given, joining the schedule already there
+// or starting one. With mode "move" they leave the original schedule; with
+// "copy" they stay on it too. Each carried item links back to where it was
+// first planned, and an item already carried to toDate is not carried again.
+// If either schedule changes while this runs, nothing is carried and it
+// answers 409.
+func RolloverSchedule(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Date   string `json:"date"`
+		ToDate string `json:"toDate"`
+		Mode   string `json:"mode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Mode == "" {
+		input.Mode = "move"
+	}
+	if input.Mode != "move" && input.Mode != "copy" {
+		http.Error(w, "mode must be move or copy", http.StatusBadRequest)
+		return
+	}
+
+	loc := userLocation(r)
+	date, err := timeutil.ParseDate(input.Date, loc)
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+	fromDay := timeutil.StartOfDay(date, loc)
+	toDay := fromDay.AddDate(0, 0, 1)
+	if input.ToDate != "" {
+		date, err := timeutil.ParseDate(input.ToDate, loc)
+		if err != nil {
+			http.Error(w, "Invalid toDate", http.StatusBadRequest)
+			return
+		}
+		toDay = timeutil.StartOfDay(date, loc)
+	}
+	if toDay.Equal(fromDay) {
+		http.Error(w, "toDate must differ from date", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("schedules")
+
+	materializeScheduleRange(r.Context(), userObjID, fromDay, fromDay, loc)
+	materializeScheduleRange(r.Context(), userObjID, toDay, toDay, loc)
+	schedules, err := findSchedulesOn(r.Context(), userObjID, []time.Time{fromDay, toDay}, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	source := schedules[fromDay.Format(timeutil.DateLayout)]
+	if source == nil {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return
+	}
+	now := time.Now()
+	target := schedules[toDay.Format(timeutil.DateLayout)]
+	isNew := target == nil
+	if isNew {
+		target = newScheduleOn(userObjID, toDay, []models.ScheduleItem{}, now)
+	}
+
+	carried := map[primitive.ObjectID]bool{}
+	for _, item := range target.Items {
+		if item.Origin != nil {
+			carried[item.Origin.ItemID] = true
+		}
+	}
+
+	kept := []models.ScheduleItem{}
+	rolled, skipped := 0, 0
+	var rolledIDs []primitive.ObjectID
+	for _, item := range source.Items {
+		if item.Completed {
+			kept = append(kept, item)
+			continue
+		}
+		origin := item.Origin
+		if origin == nil {
+			origin = &models.ScheduleItemOrigin{ScheduleID: source.ID, ItemID: item.ID, Date: source.Date}
+		}
+		if carried[origin.ItemID] {
+			skipped++
+			kept = append(kept, item)
+			continue
+		}
+		if input.Mode == "copy" {
+			kept = append(kept, item)
+		}
+		clone := item
+		clone.ID = primitive.NewObjectID()
+		clone.Origin = origin
+		target.Items = append(target.Items, clone)
+		rolledIDs = append(rolledIDs, clone.ID)
+		rolled++
+	}
+
+	if rolled == 0 {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data": map[string]interface{}{
+				"from":    source,
+				"to":      nil,
+				"items":   0,
+				"skipped": skipped,
+			},
+			"conflicts": []scheduleConflict{},
+		})
+		return
+	}
+
+	conflicts := datedOverlaps(target.Items, toDay.Format(timeutil.DateLayout))
+	if writeOverlapConflicts(w, r, overlapsInvolving(conflicts, rolledIDs)) {
+		return
+	}
+
+	// Both schedules are only written if nobody changed them since they
+	// were read. The source goes first, so a failure part way leaves the
+	// moved items on the source alone, never on both days; the source is
+	// put back if the target cannot be written.
+	original := *source
+	if input.Mode == "move" {
+		source.Items = kept
+		source.CalculateStats()
+		markOccurrenceEdited(source)
+		saved, err := saveScheduleIfUnchanged(r.Context(), source, original.UpdatedAt)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !saved {
+			writeScheduleChangedMeanwhile(w)
+			return
+		}
+	}
+	restoreSource := func() {
+		if input.Mode != "move" {
+			return
+		}
+		restored := original
+		saved, err := saveScheduleIfUnchanged(r.Context(), &restored, source.UpdatedAt)
+		if err == nil && !saved {
+			err = errors.New("it was changed meanwhile")
+		}
+		if err != nil {
+			log.Printf("⚠️ Failed to put rolled over items back on schedule %s: %v", source.ID.Hex(), err)
+		}
+	}
+
+	target.CalculateStats()
+	saved := true
+	if isNew {
+		target.UpdatedAt = now
+		_, err = collection.InsertOne(r.Context(), target)
+	} else {
+		markOccurrenceEdited(target)
+		saved, err = saveScheduleIfUnchanged(r.Context(), target, target.UpdatedAt)
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		restoreSource()
+		writeScheduleCreatedMeanwhile(w)
+		return
+	}
+	if err != nil {
+		restoreSource()
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !saved {
+		restoreSource()
+		writeScheduleChangedMeanwhile(w)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"from":    source,
+			"to":      target,
+			"items":   rolled,
+			"skipped": skipped,
+		},
+		"conflicts": conflicts,
+	})
+}
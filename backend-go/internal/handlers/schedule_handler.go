@@ -9,47 +9,15 @@ import (
 	"service-exchange-backend-go/internal/auth"
 	"service-exchange-backend-go/internal/database"
 	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func calculateScheduleStats(schedule *models.Schedule) {
-	var totalHours float64
-	completedItems := 0
-
-	for _, item := range schedule.Items {
-		start, _ := time.Parse("15:04", item.StartTime)
-		end, _ := time.Parse("15:04", item.EndTime)
-
-		diff := end.Sub(start).Hours()
-		if diff < 0 {
-			diff += 24
-		}
-		totalHours += diff
-
-		if item.Completed {
-			completedItems++
-		}
-	}
-
-	schedule.TotalHours = totalHours
-
-	if len(schedule.Items) > 0 {
-		completionPercentage := (float64(completedItems) / float64(len(schedule.Items))) * 100
-		if completionPercentage == 0 {
-			schedule.Status = models.ScheduleStatusPlanned
-		} else if completionPercentage < 100 {
-			schedule.Status = models.ScheduleStatusInProgress
-		} else {
-			schedule.Status = models.ScheduleStatusCompleted
-		}
-	} else {
-		schedule.Status = models.ScheduleStatusPlanned
-	}
-}
-
 func GetSchedules(w http.ResponseWriter, r *http.Request) {
 	startDateStr := r.URL.Query().Get("startDate")
 	endDateStr := r.URL.Query().Get("endDate")
@@ -59,16 +27,12 @@ func GetSchedules(w http.ResponseWriter, r *http.Request) {
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	query := bson.M{"user": userObjID}
 
-	if startDateStr != "" && endDateStr != "" {
-		startDate, _ := time.Parse(time.RFC3339, startDateStr)
-		endDate, _ := time.Parse(time.RFC3339, endDateStr)
-		if startDate.IsZero() {
-			startDate, _ = time.Parse("2006-01-02", startDateStr)
-			endDate, _ = time.Parse("2006-01-02", endDateStr)
-		}
-		if !startDate.IsZero() {
-			query["date"] = bson.M{"$gte": startDate, "$lte": endDate}
-		}
+	loc := userLocation(r)
+	if dateQuery := dateRangeQuery(startDateStr, endDateStr, loc); dateQuery != nil {
+		query["date"] = dateQuery
+		// Recurring templates fill in the requested days on first read.
+		from, to := dateQuery["$gte"].(time.Time), dateQuery["$lt"].(time.Time).AddDate(0, 0, -1)
+		materializeScheduleRange(r.Context(), userObjID, from, to, loc)
 	}
 	if status != "" {
 		query["status"] = status
@@ -109,12 +73,21 @@ func CreateSchedule(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(auth.UserContextKey).(string)
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 
-	date, _ := time.Parse(time.RFC3339, input.Date)
-	if date.IsZero() {
-		date, _ = time.Parse("2006-01-02", input.Date)
+	loc := userLocation(r)
+	date, err := timeutil.ParseDate(input.Date, loc)
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+	scheduleDate := timeutil.StartOfDay(date, loc)
+
+	if msg := checkItemTimes(input.Items); msg != "" {
+		writeItemTimesError(w, msg)
+		return
+	}
+	if _, ok := loadLinkedSkills(w, r, userObjID, itemSkillIDs(input.Items)); !ok {
+		return
 	}
-	y, m, d := date.Date()
-	scheduleDate := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
 
 	collection := database.GetCollection("schedules")
 	count, _ := collection.CountDocuments(r.Context(), bson.M{
@@ -130,16 +103,11 @@ func CreateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dayType := "Weekday"
-	if scheduleDate.Weekday() == time.Saturday || scheduleDate.Weekday() == time.Sunday {
-		dayType = "Weekend"
-	}
-
 	schedule := models.Schedule{
 		ID:        primitive.NewObjectID(),
 		User:      userObjID,
 		Date:      scheduleDate,
-		DayType:   dayType,
+		DayType:   models.ScheduleDayType(scheduleDate),
 		Items:     input.Items,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
@@ -151,21 +119,49 @@ func CreateSchedule(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	calculateScheduleStats(&schedule)
+	conflicts, ok := checkScheduleOverlaps(w, r, schedule.Items, changedItemIDs(nil, schedule.Items))
+	if !ok {
+		return
+	}
+	schedule.CalculateStats()
 
-	_, err := collection.InsertOne(r.Context(), schedule)
+	_, err = collection.InsertOne(r.Context(), schedule)
+	if mongo.IsDuplicateKeyError(err) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Schedule already exists for this date",
+		})
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	startPracticedSkills(r.Context(), userObjID, completedItemSkillIDs(schedule.Items))
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    schedule,
+		"success":   true,
+		"data":      schedule,
+		"conflicts": conflicts,
 	})
 }
 
+// schedulePatchSchema lists the fields UpdateSchedule accepts. Status is
+// recalculated from the items, so a client-sent status only survives until
+// the next stats pass.
+var schedulePatchSchema = patch.Schema{
+	"date":    {Kind: patch.String, Check: checkDate},
+	"items":   {Kind: patch.List, Of: []models.ScheduleItem{}},
+	"dayType": {Kind: patch.String, Check: patch.OneOf("Weekday", "Weekend")},
+	"status": {Kind: patch.String, Check: patch.OneOf(
+		string(models.ScheduleStatusPlanned),
+		string(models.ScheduleStatusInProgress),
+		string(models.ScheduleStatusCompleted),
+	)},
+}
+
 func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	id := parts[len(parts)-1]
@@ -175,14 +171,9 @@ func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input struct {
-		Date    string                `json:"date"`
-		Items   []models.ScheduleItem `json:"items"`
-		DayType string                `json:"dayType"`
-		Status  string                `json:"status"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	changes, err := patch.Decode(r.Body, schedulePatchSchema)
+	if err != nil {
+		writePatchError(w, err)
 		return
 	}
 
@@ -197,29 +188,39 @@ func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if input.Date != "" {
-		date, _ := time.Parse(time.RFC3339, input.Date)
-		if date.IsZero() {
-			date, _ = time.Parse("2006-01-02", input.Date)
-		}
-		schedule.Date = date
+	previousDate := schedule.Date
+	previousItems := schedule.Items
+	if changes.Has("date") {
+		loc := userLocation(r)
+		date, _ := timeutil.ParseDate(changes.Values["date"].(string), loc)
+		changes.Values["date"] = timeutil.StartOfDay(date, loc)
 	}
-	if input.Items != nil {
-		schedule.Items = input.Items
-		for i := range schedule.Items {
-			if schedule.Items[i].ID.IsZero() {
-				schedule.Items[i].ID = primitive.NewObjectID()
-			}
-		}
+	if err := changes.Apply(&schedule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if msg := checkItemTimes(schedule.Items); msg != "" && changes.Has("items") {
+		writeItemTimesError(w, msg)
+		return
 	}
-	if input.DayType != "" {
-		schedule.DayType = input.DayType
+	if _, ok := loadLinkedSkills(w, r, userObjID, itemSkillIDs(schedule.Items)); !ok {
+		return
+	}
+	for i := range schedule.Items {
+		if schedule.Items[i].ID.IsZero() {
+			schedule.Items[i].ID = primitive.NewObjectID()
+		}
 	}
-	if input.Status != "" {
-		schedule.Status = models.ScheduleStatus(input.Status)
+	var conflicts []scheduleConflict
+	if changes.Has("items") {
+		var ok bool
+		if conflicts, ok = checkScheduleOverlaps(w, r, schedule.Items, changedItemIDs(previousItems, schedule.Items)); !ok {
+			return
+		}
 	}
 
-	calculateScheduleStats(&schedule)
+	schedule.CalculateStats()
+	markOccurrenceEdited(&schedule)
 	schedule.UpdatedAt = time.Now()
 
 	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID}, bson.M{"$set": schedule})
@@ -227,10 +228,16 @@ func UpdateSchedule(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	// A moved occurrence must not be recreated on its old date.
+	if schedule.TemplateID != nil && !schedule.Date.Equal(previousDate) {
+		skipTemplateOccurrence(r.Context(), *schedule.TemplateID, previousDate)
+	}
+	startPracticedSkills(r.Context(), userObjID, completedItemSkillIDs(schedule.Items))
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    schedule,
+		"success":   true,
+		"data":      schedule,
+		"conflicts": conflicts,
 	})
 }
 
@@ -247,14 +254,19 @@ func DeleteSchedule(w http.ResponseWriter, r *http.Request) {
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	collection := database.GetCollection("schedules")
 
-	res, err := collection.DeleteOne(r.Context(), bson.M{"_id": objID, "user": userObjID})
+	var schedule models.Schedule
+	err = collection.FindOneAndDelete(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "Schedule not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	if res.DeletedCount == 0 {
-		http.Error(w, "Schedule not found", http.StatusNotFound)
-		return
+	// A deleted occurrence must not be recreated the next time it is read.
+	if schedule.TemplateID != nil {
+		skipTemplateOccurrence(r.Context(), *schedule.TemplateID, schedule.Date)
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -281,16 +293,31 @@ func AddScheduleItem(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("schedules")
+
+	// An item linked to a skill defaults to the skill's category.
+	if item.SkillID != nil {
+		skills, ok := loadLinkedSkills(w, r, userObjID, []primitive.ObjectID{*item.SkillID})
+		if !ok {
+			return
+		}
+		if item.Category == "" {
+			item.Category = skills[*item.SkillID].Category
+		}
+	}
 	if item.Title == "" || item.StartTime == "" || item.EndTime == "" || item.Category == "" {
 		http.Error(w, "Missing required fields", http.StatusBadRequest)
 		return
 	}
+	if _, _, err := item.Window(); err != nil {
+		writeItemTimesError(w, itemTimesMessage(err))
+		return
+	}
 	item.ID = primitive.NewObjectID()
 
-	userID := r.Context().Value(auth.UserContextKey).(string)
-	userObjID, _ := primitive.ObjectIDFromHex(userID)
-	collection := database.GetCollection("schedules")
-
 	var schedule models.Schedule
 	err = collection.FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&schedule)
 	if err != nil {
@@ -299,14 +326,21 @@ func AddScheduleItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	schedule.Items = append(schedule.Items, item)
-	calculateScheduleStats(&schedule)
+	conflicts, ok := checkScheduleOverlaps(w, r, schedule.Items, []primitive.ObjectID{item.ID})
+	if !ok {
+		return
+	}
+	schedule.CalculateStats()
+	markOccurrenceEdited(&schedule)
 	schedule.UpdatedAt = time.Now()
 
 	collection.UpdateOne(r.Context(), bson.M{"_id": objID}, bson.M{"$set": schedule})
+	startPracticedSkills(r.Context(), userObjID, completedItemSkillIDs([]models.ScheduleItem{item}))
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    schedule,
+		"success":   true,
+		"data":      schedule,
+		"conflicts": conflicts,
 	})
 }
 
@@ -336,7 +370,8 @@ func DeleteScheduleItem(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	schedule.Items = newItems
-	calculateScheduleStats(&schedule)
+	schedule.CalculateStats()
+	markOccurrenceEdited(&schedule)
 	schedule.UpdatedAt = time.Now()
 
 	collection.UpdateOne(r.Context(), bson.M{"_id": scheduleObjID}, bson.M{"$set": schedule})
@@ -373,6 +408,36 @@ func GetSchedule(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// scheduleItemPatchSchema lists the fields UpdateScheduleItem accepts.
+var scheduleItemPatchSchema = patch.Schema{
+	"title":       {Kind: patch.String, Check: patch.NotBlank},
+	"description": {Kind: patch.String, Nullable: true},
+	"startTime":   {Kind: patch.String, Check: checkStartTime},
+	"endTime":     {Kind: patch.String, Check: checkEndTime},
+	"category":    {Kind: patch.String, Check: patch.NotBlank},
+	"priority":    {Kind: patch.String},
+	"completed":   {Kind: patch.Bool},
+	"notes":       {Kind: patch.String, Nullable: true},
+	"skillId":     {Kind: patch.String, Nullable: true, Check: checkObjectID},
+}
+
+// checkStartTime and checkEndTime only check the format; whether the end
+// comes after the start is left to ScheduleItem.Window once both are known.
+func checkStartTime(v interface{}) string {
+	if _, ok := models.ClockMinutes(v.(string)); !ok {
+		return "must be a time in HH:MM format"
+	}
+	return ""
+}
+
+// checkEndTime also accepts "24:00" for the end of the day.
+func checkEndTime(v interface{}) string {
+	if strings.TrimSpace(v.(string)) == "24:00" {
+		return ""
+	}
+	return checkStartTime(v)
+}
+
 func UpdateScheduleItem(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	// .../schedules/:id/items/:itemId
@@ -386,48 +451,67 @@ func UpdateScheduleItem(w http.ResponseWriter, r *http.Request) {
 	scheduleObjID, _ := primitive.ObjectIDFromHex(scheduleId)
 	itemObjID, _ := primitive.ObjectIDFromHex(itemId)
 
-	var input map[string]interface{}
-	json.NewDecoder(r.Body).Decode(&input)
+	changes, err := patch.Decode(r.Body, scheduleItemPatchSchema)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
 
 	userID := r.Context().Value(auth.UserContextKey).(string)
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	collection := database.GetCollection("schedules")
 
 	var schedule models.Schedule
-	err := collection.FindOne(r.Context(), bson.M{"_id": scheduleObjID, "user": userObjID}).Decode(&schedule)
+	err = collection.FindOne(r.Context(), bson.M{"_id": scheduleObjID, "user": userObjID}).Decode(&schedule)
 	if err != nil {
 		http.Error(w, "Schedule not found", http.StatusNotFound)
 		return
 	}
 
-	found := false
+	var updated *models.ScheduleItem
 	for i, item := range schedule.Items {
 		if item.ID == itemObjID {
-			if val, ok := input["title"]; ok { schedule.Items[i].Title = val.(string) }
-			if val, ok := input["description"]; ok { schedule.Items[i].Description = val.(string) }
-			if val, ok := input["startTime"]; ok { schedule.Items[i].StartTime = val.(string) }
-			if val, ok := input["endTime"]; ok { schedule.Items[i].EndTime = val.(string) }
-			if val, ok := input["category"]; ok { schedule.Items[i].Category = val.(string) }
-			if val, ok := input["priority"]; ok { schedule.Items[i].Priority = val.(string) }
-			if val, ok := input["completed"]; ok { schedule.Items[i].Completed = val.(bool) }
-			if val, ok := input["notes"]; ok { schedule.Items[i].Notes = val.(string) }
-
-			found = true
+			if err := changes.Apply(&schedule.Items[i]); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			schedule.Items[i].ID = item.ID
+
+			updated = &schedule.Items[i]
 			break
 		}
 	}
 
-	if !found {
+	if updated == nil {
 		http.Error(w, "Item not found", http.StatusNotFound)
 		return
 	}
+	if changes.Has("skillId") {
+		if _, ok := loadLinkedSkills(w, r, userObjID, itemSkillIDs([]models.ScheduleItem{*updated})); !ok {
+			return
+		}
+	}
+	var conflicts []scheduleConflict
+	if changes.Has("startTime") || changes.Has("endTime") {
+		if _, _, err := updated.Window(); err != nil {
+			writeItemTimesError(w, itemTimesMessage(err))
+			return
+		}
+		var ok bool
+		if conflicts, ok = checkScheduleOverlaps(w, r, schedule.Items, []primitive.ObjectID{updated.ID}); !ok {
+			return
+		}
+	}
 
-	calculateScheduleStats(&schedule)
+	schedule.CalculateStats()
+	markOccurrenceEdited(&schedule)
 	collection.UpdateOne(r.Context(), bson.M{"_id": scheduleObjID}, bson.M{"$set": schedule})
+	startPracticedSkills(r.Context(), userObjID, completedItemSkillIDs([]models.ScheduleItem{*updated}))
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    schedule,
+		"success":   true,
+		"data":      schedule,
+		"conflicts": conflicts,
 	})
 }
 
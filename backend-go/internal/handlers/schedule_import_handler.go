@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/ical"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/scheduleimport"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type scheduleImportInput struct {
+	Content         string                        `json:"content"`
+	StartDate       string                        `json:"startDate"`
+	EndDate         string                        `json:"endDate"`
+	CategoryRules   []scheduleimport.CategoryRule `json:"categoryRules"`
+	DefaultCategory string                        `json:"defaultCategory"`
+	DryRun          bool                          `json:"dryRun"`
+}
+
+// readScheduleImport reads either a multipart upload, with the calendar in
+// the "file" field and the options as form fields (categoryRules as JSON),
+// or a JSON body with the calendar in content.
+func readScheduleImport(r *http.Request) (*scheduleImportInput, error) {
+	var input scheduleImportInput
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return nil, err
+		}
+		return &input, nil
+	}
+
+	if err := r.ParseMultipartForm(maxImportBytes); err != nil {
+		return nil, err
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, errors.New("file is required")
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	input.Content = string(content)
+	input.StartDate = r.FormValue("startDate")
+	input.EndDate = r.FormValue("endDate")
+	input.DefaultCategory = r.FormValue("defaultCategory")
+	input.DryRun = r.FormValue("dryRun") == "true"
+	if rules := r.FormValue("categoryRules"); rules != "" {
+		if err := json.Unmarshal([]byte(rules), &input.CategoryRules); err != nil {
+			return nil, errors.New("categoryRules must be a JSON list of {keyword, category}")
+		}
+	}
+	return &input, nil
+}
+
+// ImportSchedules adds the events of an uploaded .ics file between startDate
+// and endDate to the user's schedules. See scheduleimport.Import for how events become
+// items and how re-imports are recognised.
+func ImportSchedules(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	input, err := readScheduleImport(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(input.Content) == "" {
+		http.Error(w, "Calendar content is required", http.StatusBadRequest)
+		return
+	}
+	for _, rule := range input.CategoryRules {
+		if strings.TrimSpace(rule.Keyword) == "" || strings.TrimSpace(rule.Category) == "" {
+			http.Error(w, "Every category rule needs a keyword and a category", http.StatusBadRequest)
+			return
+		}
+	}
+
+	loc := userLocation(r)
+	from, err := timeutil.ParseDate(input.StartDate, loc)
+	if err != nil {
+		http.Error(w, "Invalid startDate", http.StatusBadRequest)
+		return
+	}
+	to, err := timeutil.ParseDate(input.EndDate, loc)
+	if err != nil {
+		http.Error(w, "Invalid endDate", http.StatusBadRequest)
+		return
+	}
+	from, to = timeutil.StartOfDay(from, loc), timeutil.StartOfDay(to, loc)
+	if to.Before(from) {
+		http.Error(w, "endDate must not be before startDate", http.StatusBadRequest)
+		return
+	}
+	if daysBetween(from, to) >= maxPrefillDays {
+		http.Error(w, "Date range is too long", http.StatusBadRequest)
+		return
+	}
+
+	events, err := ical.Parse(bytes.NewReader([]byte(input.Content)), loc)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	report, err := scheduleimport.Import(r.Context(), userObjID, events, scheduleimport.Options{
+		From:            from,
+		To:              to,
+		Location:        loc,
+		Rules:           input.CategoryRules,
+		DefaultCategory: strings.TrimSpace(input.DefaultCategory),
+		Strict:          userConflictMode(r) == models.ScheduleConflictsStrict,
+		DryRun:          input.DryRun,
+	})
+	if errors.Is(err, scheduleimport.ErrScheduleCreated) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/rrule"
+	"service-exchange-backend-go/internal/scheduletemplates"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func checkRRule(v interface{}) string {
+	if _, err := rrule.Parse(v.(string)); err != nil {
+		return "is not a valid recurrence rule: " + err.Error()
+	}
+	return ""
+}
+
+// checkTemplateItems returns a message for the first item missing a field
+// every occurrence needs, or an empty string.
+func checkTemplateItems(items []models.ScheduleItem) string {
+	for _, item := range items {
+		if strings.TrimSpace(item.Title) == "" || item.Category == "" {
+			return "Every item needs a title and a category"
+		}
+	}
+	return checkItemTimes(items)
+}
+
+func prepareTemplateItems(items []models.ScheduleItem) []models.ScheduleItem {
+	if items == nil {
+		return []models.ScheduleItem{}
+	}
+	for i := range items {
+		if items[i].ID.IsZero() {
+			items[i].ID = primitive.NewObjectID()
+		}
+		items[i].Completed = false
+	}
+	return items
+}
+
+// materializeScheduleRange creates the occurrences of all of the user's
+// templates in a date range that is about to be read, capped at
+// maxPrefillDays. Failures are logged; the read goes ahead regardless.
+func materializeScheduleRange(ctx context.Context, userObjID primitive.ObjectID, from, to time.Time, loc *time.Location) {
+	if limit := from.AddDate(0, 0, maxPrefillDays-1); to.After(limit) {
+		to = limit
+	}
+	if err := scheduletemplates.MaterializeRange(ctx, userObjID, from, to, loc); err != nil {
+		log.Printf("⚠️ Failed to materialize schedule templates: %v", err)
+	}
+}
+
+// syncTemplateOccurrences brings the template's unmodified occurrences from
+// from through to (open-ended when to is zero) in line with the template:
+// ones its rule or exceptions no longer produce are deleted, and the rest get
+// its current items. Items the template already had keep their IDs, since
+// ItemsOn derives them from the template item and the date. Dates the rule newly produces are filled in up to the
+// last existing occurrence; later ones appear as they are fetched.
+func syncTemplateOccurrences(ctx context.Context, template *models.ScheduleTemplate, from, to time.Time, loc *time.Location) error {
+	collection := database.GetCollection("schedules")
+	dateFilter := bson.M{"$gte": from}
+	if !to.IsZero() {
+		dateFilter["$lt"] = to.AddDate(0, 0, 1)
+	}
+	cursor, err := collection.Find(ctx, bson.M{
+		"user":       template.User,
+		"templateId": template.ID,
+		"modified":   bson.M{"$ne": true},
+		"date":       dateFilter,
+	})
+	if err != nil {
+		return err
+	}
+	var occurrences []models.Schedule
+	if err = cursor.All(ctx, &occurrences); err != nil {
+		return err
+	}
+
+	last := from
+	for _, s := range occurrences {
+		if s.Date.After(last) {
+			last = s.Date
+		}
+	}
+	rule, err := rrule.Parse(template.RRule)
+	if err != nil {
+		return err
+	}
+	produced := make(map[string]bool)
+	for _, day := range rule.Between(timeutil.StartOfDay(template.StartDate, loc), from, last) {
+		produced[day.Format(timeutil.DateLayout)] = true
+	}
+
+	now := time.Now()
+	var writes []mongo.WriteModel
+	for _, s := range occurrences {
+		day := timeutil.StartOfDay(s.Date, loc)
+		items, ok := template.ItemsOn(day)
+		if !ok || !produced[day.Format(timeutil.DateLayout)] {
+			writes = append(writes, mongo.NewDeleteOneModel().SetFilter(bson.M{"_id": s.ID}))
+			continue
+		}
+		s.Items = items
+		s.CalculateStats()
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": s.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"items":      s.Items,
+				"totalHours": s.TotalHours,
+				"status":     s.Status,
+				"updatedAt":  now,
+			}}))
+	}
+	if len(writes) > 0 {
+		if _, err := collection.BulkWrite(ctx, writes); err != nil {
+			return err
+		}
+	}
+
+	_, err = scheduletemplates.Materialize(ctx, template.User, []models.ScheduleTemplate{*template}, from, last, loc)
+	return err
+}
+
+// markOccurrenceEdited stops template edits from reaching a schedule the
+// user has changed by hand.
+func markOccurrenceEdited(schedule *models.Schedule) {
+	if schedule.TemplateID != nil {
+		schedule.Modified = true
+	}
+}
+
+// skipTemplateOccurrence records that a template's occurrence on day is gone,
+// so that it is not materialized again after being deleted or moved.
+func skipTemplateOccurrence(ctx context.Context, templateID primitive.ObjectID, day time.Time) {
+	collection := database.GetCollection("scheduletemplates")
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": templateID},
+		bson.M{"$pull": bson.M{"exceptions": bson.M{"date": day}}})
+	if err == nil {
+		_, err = collection.UpdateOne(ctx, bson.M{"_id": templateID},
+			bson.M{"$push": bson.M{"exceptions": models.ScheduleException{Date: day, Type: models.ScheduleExceptionSkip}}})
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to skip occurrence of schedule template %s: %v", templateID.Hex(), err)
+	}
+}
+
+// loadScheduleTemplate finds the template named in a
+// /api/schedule-templates/:id/... path.
+func loadScheduleTemplate(w http.ResponseWriter, r *http.Request) (*models.ScheduleTemplate, bool) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/schedule-templates/")
+	id, _, _ := strings.Cut(path, "/")
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var template models.ScheduleTemplate
+	err = database.GetCollection("scheduletemplates").FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&template)
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return nil, false
+	}
+	return &template, true
+}
+
+func GetScheduleTemplates(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	templates, err := scheduletemplates.Find(r.Context(), bson.M{"user": userObjID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(templates),
+		"data":    templates,
+	})
+}
+
+// CreateScheduleTemplate saves a recurring schedule. Occurrences are created
+// as their dates are fetched, or up front with MaterializeScheduleTemplate.
+func CreateScheduleTemplate(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name      string                `json:"name"`
+		RRule     string                `json:"rrule"`
+		StartDate string                `json:"startDate"`
+		Items     []models.ScheduleItem `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || input.RRule == "" {
+		http.Error(w, "Name and rrule are required", http.StatusBadRequest)
+		return
+	}
+	rule, err := rrule.Parse(input.RRule)
+	if err != nil {
+		http.Error(w, "Invalid rrule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := checkTemplateItems(input.Items); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	loc := userLocation(r)
+	startDate := timeutil.StartOfDay(time.Now(), loc)
+	if input.StartDate != "" {
+		date, err := timeutil.ParseDate(input.StartDate, loc)
+		if err != nil {
+			http.Error(w, "Invalid start date", http.StatusBadRequest)
+			return
+		}
+		startDate = timeutil.StartOfDay(date, loc)
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	if _, ok := loadLinkedSkills(w, r, userObjID, itemSkillIDs(input.Items)); !ok {
+		return
+	}
+
+	template := models.ScheduleTemplate{
+		ID:         primitive.NewObjectID(),
+		User:       userObjID,
+		Name:       input.Name,
+		RRule:      rule.String(),
+		StartDate:  startDate,
+		Items:      prepareTemplateItems(input.Items),
+		Exceptions: []models.ScheduleException{},
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if _, err := database.GetCollection("scheduletemplates").InsertOne(r.Context(), template); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    template,
+	})
+}
+
+func GetScheduleTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := loadScheduleTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// scheduleTemplatePatchSchema lists the fields UpdateScheduleTemplate accepts.
+// Exceptions have their own endpoints.
+var scheduleTemplatePatchSchema = patch.Schema{
+	"name":      {Kind: patch.String, Check: patch.NotBlank},
+	"rrule":     {Kind: patch.String, Check: checkRRule},
+	"startDate": {Kind: patch.String, Check: checkDate},
+	"items":     {Kind: patch.List, Of: []models.ScheduleItem{}},
+}
+
+// UpdateScheduleTemplate edits a template and carries the edit over to its
+// occurrences from today on that have not been edited on their own.
+func UpdateScheduleTemplate(w http.ResponseWriter, r *http.Request) {
+	changes, err := patch.Decode(r.Body, scheduleTemplatePatchSchema)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	template, ok := loadScheduleTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	loc := userLocation(r)
+	if changes.Has("name") {
+		changes.Values["name"] = strings.TrimSpace(changes.Values["name"].(string))
+	}
+	if changes.Has("rrule") {
+		rule, _ := rrule.Parse(changes.Values["rrule"].(string))
+		changes.Values["rrule"] = rule.String()
+	}
+	if changes.Has("startDate") {
+		date, _ := timeutil.ParseDate(changes.Values["startDate"].(string), loc)
+		changes.Values["startDate"] = timeutil.StartOfDay(date, loc)
+	}
+	if changes.Has("items") {
+		items := changes.Values["items"].([]models.ScheduleItem)
+		if msg := checkTemplateItems(items); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		if _, ok := loadLinkedSkills(w, r, template.User, itemSkillIDs(items)); !ok {
+			return
+		}
+		changes.Values["items"] = prepareTemplateItems(items)
+	}
+
+	if err := changes.Apply(template); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	template.UpdatedAt = time.Now()
+
+	_, err = database.GetCollection("scheduletemplates").UpdateOne(r.Context(), bson.M{"_id": template.ID}, bson.M{"$set": template})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := syncTemplateOccurrences(r.Context(), template, timeutil.StartOfDay(time.Now(), loc), time.Time{}, loc); err != nil {
+		log.Printf("⚠️ Failed to update occurrences of schedule template %s: %v", template.ID.Hex(), err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// DeleteScheduleTemplate removes a template with its unedited occurrences
+// from today on. Past and edited occurrences stay as ordinary schedules.
+func DeleteScheduleTemplate(w http.ResponseWriter, r *http.Request) {
+	template, ok := loadScheduleTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := database.GetCollection("scheduletemplates").DeleteOne(r.Context(), bson.M{"_id": template.ID}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	collection := database.GetCollection("schedules")
+	today := timeutil.StartOfDay(time.Now(), userLocation(r))
+	res, err := collection.DeleteMany(r.Context(), bson.M{
+		"user":       template.User,
+		"templateId": template.ID,
+		"modified":   bson.M{"$ne": true},
+		"date":       bson.M{"$gte": today},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	collection.UpdateMany(r.Context(),
+		bson.M{"user": template.User, "templateId": template.ID},
+		bson.M{"$unset": bson.M{"templateId": "", "modified": ""}},
+	)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":            true,
+		"message":            "Template deleted successfully",
+		"occurrencesDeleted": res.DeletedCount,
+	})
+}
+
+// MaterializeScheduleTemplate creates the template's occurrences for a date
+// range up front, at most maxPrefillDays at a time.
+func MaterializeScheduleTemplate(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		StartDate string `json:"startDate"`
+		EndDate   string `json:"endDate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	loc := userLocation(r)
+	from, err := timeutil.ParseDate(input.StartDate, loc)
+	if err != nil {
+		http.Error(w, "Invalid start date", http.StatusBadRequest)
+		return
+	}
+	to, err := timeutil.ParseDate(input.EndDate, loc)
+	if err != nil {
+		http.Error(w, "Invalid end date", http.StatusBadRequest)
+		return
+	}
+	from, to = timeutil.StartOfDay(from, loc), timeutil.StartOfDay(to, loc)
+	if to.Before(from) {
+		http.Error(w, "End date must not be before start date", http.StatusBadRequest)
+		return
+	}
+	if daysBetween(from, to) >= maxPrefillDays {
+		http.Error(w, "Date range is too long", http.StatusBadRequest)
+		return
+	}
+
+	template, ok := loadScheduleTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := scheduletemplates.Materialize(r.Context(), template.User, []models.ScheduleTemplate{*template}, from, to, loc)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"created": created,
+	})
+}
+
+// SetScheduleException skips one occurrence of a template or gives it its
+// own items, replacing any earlier exception for that date. An occurrence
+// that already exists and has not been edited is updated straight away.
+func SetScheduleException(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Date  string                       `json:"date"`
+		Type  models.ScheduleExceptionType `json:"type"`
+		Items []models.ScheduleItem        `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Type != models.ScheduleExceptionSkip && input.Type != models.ScheduleExceptionModify {
+		http.Error(w, "Type must be skip or modify", http.StatusBadRequest)
+		return
+	}
+	if input.Type == models.ScheduleExceptionSkip {
+		input.Items = nil
+	} else if msg := checkTemplateItems(input.Items); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	loc := userLocation(r)
+	date, err := timeutil.ParseDate(input.Date, loc)
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+	day := timeutil.StartOfDay(date, loc)
+
+	template, ok := loadScheduleTemplate(w, r)
+	if !ok {
+		return
+	}
+	rule, err := rrule.Parse(template.RRule)
+	if err != nil || !rule.Occurs(timeutil.StartOfDay(template.StartDate, loc), day) {
+		http.Error(w, "Template has no occurrence on this date", http.StatusBadRequest)
+		return
+	}
+	if input.Type == models.ScheduleExceptionModify {
+		if _, ok := loadLinkedSkills(w, r, template.User, itemSkillIDs(input.Items)); !ok {
+			return
+		}
+		input.Items = prepareTemplateItems(input.Items)
+	}
+
+	exceptions := []models.ScheduleException{}
+	for _, exception := range template.Exceptions {
+		if !exception.Date.Equal(day) {
+			exceptions = append(exceptions, exception)
+		}
+	}
+	template.Exceptions = append(exceptions, models.ScheduleException{Date: day, Type: input.Type, Items: input.Items})
+
+	saveScheduleExceptions(w, r, template, day, http.StatusCreated)
+}
+
+// DeleteScheduleException restores a template's occurrence on a date to the
+// template's own items.
+func DeleteScheduleException(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	// .../schedule-templates/:id/exceptions/:date
+	loc := userLocation(r)
+	date, err := timeutil.ParseDate(parts[len(parts)-1], loc)
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
+	}
+	day := timeutil.StartOfDay(date, loc)
+
+	template, ok := loadScheduleTemplate(w, r)
+	if !ok {
+		return
+	}
+	exceptions := []models.ScheduleException{}
+	for _, exception := range template.Exceptions {
+		if !exception.Date.Equal(day) {
+			exceptions = append(exceptions, exception)
+		}
+	}
+	if len(exceptions) == len(template.Exceptions) {
+		http.Error(w, "Exception not found", http.StatusNotFound)
+		return
+	}
+	template.Exceptions = exceptions
+
+	saveScheduleExceptions(w, r, template, day, http.StatusOK)
+}
+
+// saveScheduleExceptions writes the template's exceptions back, applies them
+// to the occurrence on day and responds with status.
+func saveScheduleExceptions(w http.ResponseWriter, r *http.Request, template *models.ScheduleTemplate, day time.Time, status int) {
+	template.UpdatedAt = time.Now()
+	_, err := database.GetCollection("scheduletemplates").UpdateOne(r.Context(), bson.M{"_id": template.ID},
+		bson.M{"$set": bson.M{"exceptions": template.Exceptions, "updatedAt": template.UpdatedAt}})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := syncTemplateOccurrences(r.Context(), template, day, day, userLocation(r)); err != nil {
+		log.Printf("⚠️ Failed to update occurrence of schedule template %s: %v", template.ID.Hex(), err)
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    template,
+	})
+}
@@ -0,0 +1,353 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// priorityRank orders priorities from most to least urgent, with unset
+// priorities last.
+func priorityRank(p models.SkillPriority) int {
+	switch p {
+	case models.SkillPriorityHigh:
+		return 0
+	case models.SkillPriorityMedium:
+		return 1
+	case models.SkillPriorityLow:
+		return 2
+	}
+	return 3
+}
+
+// lessSkill orders skills by priority, then the way GetSkills lists them.
+func lessSkill(a, b *models.Skill) bool {
+	if ra, rb := priorityRank(a.Priority), priorityRank(b.Priority); ra != rb {
+		return ra < rb
+	}
+	if a.Category != b.Category {
+		return a.Category < b.Category
+	}
+	if a.OrderIndex != b.OrderIndex {
+		return a.OrderIndex < b.OrderIndex
+	}
+	return a.Name < b.Name
+}
+
+func fetchSkillsByID(ctx context.Context, userObjID primitive.ObjectID) (map[primitive.ObjectID]*models.Skill, error) {
+	cursor, err := database.GetCollection("skills").Find(ctx, bson.M{"user": userObjID})
+	if err != nil {
+		return nil, err
+	}
+	var skills []models.Skill
+	if err = cursor.All(ctx, &skills); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Skill, len(skills))
+	for i := range skills {
+		byID[skills[i].ID] = &skills[i]
+	}
+	return byID, nil
+}
+
+// findPrerequisiteCycle reports the cycle that giving skill the prerequisites
+// would create, as a path from skill back to itself, or nil if there is none.
+func findPrerequisiteCycle(byID map[primitive.ObjectID]*models.Skill, skill primitive.ObjectID, prerequisites []primitive.ObjectID) []primitive.ObjectID {
+	edges := func(id primitive.ObjectID) []primitive.ObjectID {
+		if id == skill {
+			return prerequisites
+		}
+		if s, ok := byID[id]; ok {
+			return s.Prerequisites
+		}
+		return nil
+	}
+
+	visited := make(map[primitive.ObjectID]bool)
+	var path []primitive.ObjectID
+	var walk func(id primitive.ObjectID) bool
+	walk = func(id primitive.ObjectID) bool {
+		path = append(path, id)
+		for _, next := range edges(id) {
+			if next == skill {
+				path = append(path, next)
+				return true
+			}
+			if !visited[next] {
+				visited[next] = true
+				if walk(next) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return false
+	}
+	if walk(skill) {
+		return path
+	}
+	return nil
+}
+
+// incompletePrerequisites lists the prerequisites of skill that are not
+// completed yet. Prerequisites that no longer exist are ignored.
+func incompletePrerequisites(skill *models.Skill, byID map[primitive.ObjectID]*models.Skill) []*models.Skill {
+	var pending []*models.Skill
+	for _, id := range skill.Prerequisites {
+		if p, ok := byID[id]; ok && p.Status != models.SkillStatusCompleted {
+			pending = append(pending, p)
+		}
+	}
+	return pending
+}
+
+// prerequisiteWarning describes a skill that was started before all of its
+// prerequisites were completed, or returns "" when there is nothing to warn
+// about.
+func prerequisiteWarning(skill *models.Skill, byID map[primitive.ObjectID]*models.Skill) string {
+	if skill.Status == models.SkillStatusUpcoming || skill.Status == "" {
+		return ""
+	}
+	pending := incompletePrerequisites(skill, byID)
+	if len(pending) == 0 {
+		return ""
+	}
+	names := make([]string, len(pending))
+	for i, p := range pending {
+		names[i] = p.Name
+	}
+	return fmt.Sprintf("'%s' was started before its prerequisites were completed: %s", skill.Name, strings.Join(names, ", "))
+}
+
+// skillStartWarnings is what UpdateSkill reports after a status change.
+func skillStartWarnings(ctx context.Context, skill models.Skill) []string {
+	warnings := []string{}
+	if len(skill.Prerequisites) == 0 {
+		return warnings
+	}
+	byID, err := fetchSkillsByID(ctx, skill.User)
+	if err != nil {
+		return warnings
+	}
+	if msg := prerequisiteWarning(&skill, byID); msg != "" {
+		warnings = append(warnings, msg)
+	}
+	return warnings
+}
+
+// UpdateSkillPrerequisites replaces a skill's prerequisites, rejecting
+// unknown skills, self references and anything that would form a cycle.
+func UpdateSkillPrerequisites(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	// .../skills/:id/prerequisites
+	id := parts[len(parts)-2]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		PrerequisiteIDs []string `json:"prerequisiteIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	byID, err := fetchSkillsByID(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	skill, ok := byID[objID]
+	if !ok {
+		http.Error(w, "Skill not found", http.StatusNotFound)
+		return
+	}
+
+	prerequisites := []primitive.ObjectID{}
+	seen := make(map[primitive.ObjectID]bool)
+	for _, hex := range input.PrerequisiteIDs {
+		prereqID, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			http.Error(w, "Invalid prerequisite ID: "+hex, http.StatusBadRequest)
+			return
+		}
+		if prereqID == objID {
+			http.Error(w, "A skill cannot be its own prerequisite", http.StatusBadRequest)
+			return
+		}
+		if _, ok := byID[prereqID]; !ok {
+			http.Error(w, "Prerequisite not found: "+hex, http.StatusNotFound)
+			return
+		}
+		if !seen[prereqID] {
+			seen[prereqID] = true
+			prerequisites = append(prerequisites, prereqID)
+		}
+	}
+
+	if cycle := findPrerequisiteCycle(byID, objID, prerequisites); cycle != nil {
+		names := make([]string, len(cycle))
+		for i, id := range cycle {
+			names[i] = byID[id].Name
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "Prerequisites would create a cycle: " + strings.Join(names, " → "),
+			"cycle":   cycle,
+		})
+		return
+	}
+
+	_, err = database.GetCollection("skills").UpdateOne(r.Context(),
+		bson.M{"_id": objID, "user": userObjID},
+		bson.M{"$set": bson.M{"prerequisites": prerequisites, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	skill.Prerequisites = prerequisites
+
+	warnings := []string{}
+	if msg := prerequisiteWarning(skill, byID); msg != "" {
+		warnings = append(warnings, msg)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"data":     skill,
+		"warnings": warnings,
+	})
+}
+
+// GetSkillRoadmap lists every skill after its prerequisites. Among skills
+// whose prerequisites are already placed, higher priority comes first. Level
+// is the length of the longest prerequisite chain leading to the skill.
+func GetSkillRoadmap(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	byID, err := fetchSkillsByID(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	remaining := make(map[primitive.ObjectID]int, len(byID))
+	dependents := make(map[primitive.ObjectID][]primitive.ObjectID)
+	var ready []*models.Skill
+	for id, skill := range byID {
+		for _, prereq := range skill.Prerequisites {
+			if _, ok := byID[prereq]; ok {
+				remaining[id]++
+				dependents[prereq] = append(dependents[prereq], id)
+			}
+		}
+		if remaining[id] == 0 {
+			ready = append(ready, skill)
+		}
+	}
+
+	level := make(map[primitive.ObjectID]int, len(byID))
+	roadmap := []map[string]interface{}{}
+	warnings := []string{}
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return lessSkill(ready[i], ready[j]) })
+		skill := ready[0]
+		ready = ready[1:]
+
+		blockedBy := []primitive.ObjectID{}
+		for _, p := range incompletePrerequisites(skill, byID) {
+			blockedBy = append(blockedBy, p.ID)
+		}
+		if msg := prerequisiteWarning(skill, byID); msg != "" {
+			warnings = append(warnings, msg)
+		}
+		roadmap = append(roadmap, map[string]interface{}{
+			"skill":     skill,
+			"level":     level[skill.ID],
+			"unlocked":  len(blockedBy) == 0,
+			"blockedBy": blockedBy,
+		})
+
+		for _, dependent := range dependents[skill.ID] {
+			if level[skill.ID]+1 > level[dependent] {
+				level[dependent] = level[skill.ID] + 1
+			}
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, byID[dependent])
+			}
+		}
+	}
+
+	// Prerequisites are checked for cycles on write, so anything left over
+	// comes from data written some other way.
+	if len(roadmap) < len(byID) {
+		warnings = append(warnings, fmt.Sprintf("%d skills are part of a prerequisite cycle and were left out", len(byID)-len(roadmap)))
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"count":    len(roadmap),
+		"data":     roadmap,
+		"warnings": warnings,
+	})
+}
+
+// GetNextSkills lists upcoming skills whose prerequisites are all completed,
+// highest priority first.
+func GetNextSkills(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	byID, err := fetchSkillsByID(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	unlockable := []*models.Skill{}
+	for _, skill := range byID {
+		if skill.Status == models.SkillStatusUpcoming && len(incompletePrerequisites(skill, byID)) == 0 {
+			unlockable = append(unlockable, skill)
+		}
+	}
+	sort.Slice(unlockable, func(i, j int) bool { return lessSkill(unlockable[i], unlockable[j]) })
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			http.Error(w, "Limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		if limit < len(unlockable) {
+			unlockable = unlockable[:limit]
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(unlockable),
+		"data":    unlockable,
+	})
+}
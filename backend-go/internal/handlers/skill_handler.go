@@ -2,22 +2,32 @@ package handlers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"service-exchange-backend-go/internal/auth"
 	"service-exchange-backend-go/internal/database"
 	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func GetSkills(w http.ResponseWriter, r *http.Request) {
 	category := r.URL.Query().Get("category")
 	status := r.URL.Query().Get("status")
+	deadline := r.URL.Query().Get("deadline")
+	if deadline != "" && deadline != deadlineOverdue && deadline != deadlineAtRisk && deadline != deadlineOnTrack {
+		http.Error(w, "Deadline must be overdue, at-risk or on-track", http.StatusBadRequest)
+		return
+	}
 
 	userID := r.Context().Value(auth.UserContextKey).(string)
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
@@ -49,6 +59,17 @@ func GetSkills(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	for i := range skills {
+		if err := ensureResourceIDs(r.Context(), &skills[i]); err != nil {
+			log.Printf("⚠️ Failed to save resource IDs for skill %s: %v", skills[i].ID.Hex(), err)
+		}
+	}
+	if deadline != "" {
+		if skills, err = filterSkillsByDeadline(r, userObjID, skills, deadline); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	groupedSkills := make(map[string][]models.Skill)
 	for _, skill := range skills {
@@ -73,6 +94,13 @@ func AddSkill(w http.ResponseWriter, r *http.Request) {
 		Resources   []models.Resource `json:"resources"`
 		Priority    string            `json:"priority"`
 		OrderIndex  *int              `json:"orderIndex"`
+
+		ProgressFromResources bool `json:"progressFromResources"`
+		AutoStart             bool `json:"autoStart"`
+		ReviewEnabled         bool `json:"reviewEnabled"`
+
+		TargetDate string           `json:"targetDate"`
+		Milestones []milestoneInput `json:"milestones"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -85,6 +113,27 @@ func AddSkill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	loc := userLocation(r)
+	var targetDate *time.Time
+	if input.TargetDate != "" {
+		date, err := timeutil.ParseDate(input.TargetDate, loc)
+		if err != nil {
+			http.Error(w, "Invalid target date", http.StatusBadRequest)
+			return
+		}
+		date = timeutil.StartOfDay(date, loc)
+		targetDate = &date
+	}
+	var milestones []models.SkillMilestone
+	for _, m := range input.Milestones {
+		milestone, msg := m.toMilestone(loc)
+		if msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		milestones = append(milestones, milestone)
+	}
+
 	userID := r.Context().Value(auth.UserContextKey).(string)
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	collection := database.GetCollection("skills")
@@ -117,40 +166,52 @@ func AddSkill(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	normalizeResources(input.Resources)
 	skill := models.Skill{
 		ID:          primitive.NewObjectID(),
 		User:        userObjID,
 		Name:        strings.TrimSpace(input.Name),
 		Category:    input.Category,
-		Status:      models.SkillStatus(input.Status),
-		Progress:    input.Progress,
 		Description: input.Description,
 		Resources:   input.Resources,
 		Priority:    models.SkillPriority(input.Priority),
 		OrderIndex:  orderIndex,
+		TargetDate:  targetDate,
+		Milestones:  milestones,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
-	}
 
-	if skill.Status == "" {
-		skill.Status = models.SkillStatusUpcoming
+		ProgressFromResources: input.ProgressFromResources,
+		AutoStart:             input.AutoStart,
+		ReviewEnabled:         input.ReviewEnabled,
 	}
 
-	if skill.Status == models.SkillStatusInProgress {
-		now := time.Now()
-		skill.StartDate = &now
+	status := models.SkillStatus(input.Status)
+	progress := input.Progress
+	if skill.ProgressFromResources && status != models.SkillStatusCompleted {
+		progress = models.ResourceProgress(skill.Resources)
+		if status == "" {
+			status = models.ProgressStatus(models.SkillStatusUpcoming, progress)
+		}
+	}
+	if status == "" {
+		status = models.SkillStatusUpcoming
+	}
+	if status == models.SkillStatusCompleted && progress == 0 {
+		progress = 100
 	}
-	if skill.Status == models.SkillStatusCompleted {
-		now := time.Now()
-		skill.CompletionDate = &now
-		skill.Progress = 100
+	if err := skill.Transition(status, progress, time.Now()); err != nil {
+		writeSkillStateError(w, err)
+		return
 	}
+	skill.Review, _ = models.ReviewFor(models.Skill{}, skill.Status, skill.ReviewEnabled, loc)
 
 	_, err := collection.InsertOne(r.Context(), skill)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	recordSkillProgress(r.Context(), nil, skill)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -159,6 +220,51 @@ func AddSkill(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// skillStateFields are the stored fields the status state machine owns.
+func skillStateFields(skill models.Skill) bson.M {
+	return bson.M{
+		"status":         skill.Status,
+		"progress":       skill.Progress,
+		"startDate":      skill.StartDate,
+		"completionDate": skill.CompletionDate,
+	}
+}
+
+// writeSkillStateError rejects a status or progress the state machine does
+// not allow.
+func writeSkillStateError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"message": "Invalid skill state: " + err.Error(),
+	})
+}
+
+// skillPatchSchema lists the fields UpdateSkill accepts. Start and completion
+// dates are derived from status changes and cannot be set directly.
+var skillPatchSchema = patch.Schema{
+	"name":     {Kind: patch.String, Check: patch.NotBlank},
+	"category": {Kind: patch.String, Check: patch.NotBlank},
+	"status": {Kind: patch.String, Check: patch.OneOf(
+		string(models.SkillStatusUpcoming),
+		string(models.SkillStatusInProgress),
+		string(models.SkillStatusCompleted),
+	)},
+	"progress":    {Kind: patch.Integer, Check: patch.Between(0, 100)},
+	"description": {Kind: patch.String, Nullable: true},
+	"resources":   {Kind: patch.List, Of: []models.Resource{}},
+	"priority": {Kind: patch.String, Check: patch.OneOf(
+		string(models.SkillPriorityHigh),
+		string(models.SkillPriorityMedium),
+		string(models.SkillPriorityLow),
+	)},
+	"orderIndex":            {Kind: patch.Integer, Check: patch.AtLeast(0)},
+	"progressFromResources": {Kind: patch.Bool},
+	"autoStart":             {Kind: patch.Bool},
+	"reviewEnabled":         {Kind: patch.Bool},
+	"targetDate":            {Kind: patch.String, Nullable: true, Check: checkDate},
+}
+
 func UpdateSkill(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	id := parts[len(parts)-1]
@@ -168,11 +274,24 @@ func UpdateSkill(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	changes, err := patch.Decode(r.Body, skillPatchSchema)
+	if err != nil {
+		writePatchError(w, err)
 		return
 	}
+	for _, key := range []string{"name", "category"} {
+		if changes.Has(key) {
+			changes.Values[key] = strings.TrimSpace(changes.Values[key].(string))
+		}
+	}
+	if changes.Has("resources") {
+		normalizeResources(changes.Values["resources"].([]models.Resource))
+	}
+	loc := userLocation(r)
+	if value, ok := changes.Values["targetDate"].(string); ok {
+		date, _ := timeutil.ParseDate(value, loc)
+		changes.Values["targetDate"] = timeutil.StartOfDay(date, loc)
+	}
 
 	userID := r.Context().Value(auth.UserContextKey).(string)
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
@@ -188,13 +307,13 @@ func UpdateSkill(w http.ResponseWriter, r *http.Request) {
 	update := bson.M{"updatedAt": time.Now()}
 
 	// Handle name/category uniqueness check if changed
-	name, hasName := input["name"].(string)
-	category, hasCategory := input["category"].(string)
+	name, hasName := changes.Values["name"].(string)
+	category, hasCategory := changes.Values["category"].(string)
 
 	if (hasName && name != skill.Name) || (hasCategory && category != skill.Category) {
 		checkName := skill.Name
 		if hasName {
-			checkName = strings.TrimSpace(name)
+			checkName = name
 		}
 		checkCategory := skill.Category
 		if hasCategory {
@@ -217,43 +336,81 @@ func UpdateSkill(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Status change logic
-	statusStr, hasStatus := input["status"].(string)
-	if hasStatus && models.SkillStatus(statusStr) != skill.Status {
-		status := models.SkillStatus(statusStr)
-		if status == models.SkillStatusInProgress && skill.StartDate == nil {
-			now := time.Now()
-			update["startDate"] = now
-		}
-		if status == models.SkillStatusCompleted && skill.CompletionDate == nil {
-			now := time.Now()
-			update["completionDate"] = now
-			update["progress"] = 100
+	// Work out the status and progress the skill ends up with and check the
+	// move against the state machine before anything is written. Derived
+	// progress follows the resources, overrides a sent progress and moves
+	// an open skill along unless a status was sent too.
+	status := skill.Status
+	statusStr, hasStatus := changes.Values["status"].(string)
+	if hasStatus {
+		status = models.SkillStatus(statusStr)
+	}
+	progress, hasProgress := changes.Values["progress"].(int)
+	if !hasProgress {
+		progress = skill.Progress
+	}
+	fromResources := skill.ProgressFromResources
+	if changes.Has("progressFromResources") {
+		fromResources = changes.Values["progressFromResources"].(bool)
+	}
+	if fromResources && status != models.SkillStatusCompleted {
+		resources := skill.Resources
+		if changes.Has("resources") {
+			resources = changes.Values["resources"].([]models.Resource)
 		}
-		if status != models.SkillStatusCompleted && skill.Status == models.SkillStatusCompleted {
-			update["completionDate"] = nil
+		progress = models.ResourceProgress(resources)
+		if !hasStatus {
+			status = models.ProgressStatus(skill.Status, progress)
 		}
 	}
+	if status == models.SkillStatusCompleted && skill.Status != models.SkillStatusCompleted && !hasProgress {
+		progress = 100
+	}
 
-	for k, v := range input {
-		if k == "name" {
-			update[k] = strings.TrimSpace(v.(string))
-		} else {
-			update[k] = v
+	// Documents saved before the rules existed may break them; they can
+	// still be edited as long as the patch leaves their state alone.
+	next := skill
+	if hasStatus || hasProgress || status != skill.Status || progress != skill.Progress {
+		if err := next.Transition(status, progress, time.Now()); err != nil {
+			writeSkillStateError(w, err)
+			return
+		}
+		for key, value := range skillStateFields(next) {
+			update[key] = value
 		}
 	}
+	delete(changes.Values, "status")
+	delete(changes.Values, "progress")
+
+	// Completing a skill, or enabling reviews on a completed one, starts its
+	// review schedule; anything else that stops it being reviewable drops it.
+	reviewEnabled := skill.ReviewEnabled
+	if changes.Has("reviewEnabled") {
+		reviewEnabled = changes.Values["reviewEnabled"].(bool)
+	}
+	if review, changed := models.ReviewFor(skill, next.Status, reviewEnabled, loc); changed {
+		update["review"] = review
+	}
 
-	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID}, bson.M{"$set": update})
+	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID}, changes.Update(update))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	before := skill
 	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&skill)
+	recordSkillProgress(r.Context(), &before, skill)
+
+	warnings := []string{}
+	if skill.Status != before.Status {
+		warnings = skillStartWarnings(r.Context(), skill)
+	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    skill,
+		"success":  true,
+		"data":     skill,
+		"warnings": warnings,
 	})
 }
 
@@ -290,6 +447,13 @@ func DeleteSkill(w http.ResponseWriter, r *http.Request) {
 		// Log error but success for deletion
 	}
 
+	// Drop the deleted skill from other skills' prerequisites
+	collection.UpdateMany(r.Context(),
+		bson.M{"user": userObjID, "prerequisites": skill.ID},
+		bson.M{"$pull": bson.M{"prerequisites": skill.ID}},
+	)
+	unlinkSkill(r.Context(), userObjID, skill.ID)
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 		"message": "Skill deleted successfully",
@@ -321,8 +485,18 @@ func GetSkillStats(w http.ResponseWriter, r *http.Request) {
 	sumProgress := 0
 
 	categoryCounts := make(map[string]int)
+	loc := userLocation(r)
+	today := timeutil.StartOfDay(time.Now(), loc)
+	reviewDebt := 0
+	overdue := 0
 
 	for _, skill := range skills {
+		if isReviewDue(skill, today) {
+			reviewDebt++
+		}
+		if isSkillOverdue(skill, today) {
+			overdue++
+		}
 		if skill.Status == models.SkillStatusCompleted {
 			completed++
 		} else if skill.Status == models.SkillStatusInProgress {
@@ -340,6 +514,7 @@ func GetSkillStats(w http.ResponseWriter, r *http.Request) {
 		completionRate = (float64(completed) / float64(total)) * 100
 		averageProgress = float64(sumProgress) / float64(total)
 	}
+	onTimeRate, averageSlipDays := deadlineStats(skills, loc)
 
 	stats := map[string]interface{}{
 		"total":           total,
@@ -349,6 +524,10 @@ func GetSkillStats(w http.ResponseWriter, r *http.Request) {
 		"completionRate":  completionRate,
 		"averageProgress": averageProgress,
 		"categoryCounts":  categoryCounts,
+		"reviewDebt":      reviewDebt,
+		"overdue":         overdue,
+		"onTimeRate":      onTimeRate,
+		"averageSlipDays": averageSlipDays,
 		"statusDistribution": map[string]int{
 			"completed":  completed,
 			"inProgress": inProgress,
@@ -361,3 +540,245 @@ func GetSkillStats(w http.ResponseWriter, r *http.Request) {
 		"stats":   stats,
 	})
 }
+
+// ReorderSkills rewrites OrderIndex from ordered lists of skill IDs, one list
+// per category. A skill listed under a category other than its own is moved
+// there. Skills left out of a listed category keep their relative order after
+// the listed ones. The older {skills: [{id, orderIndex}]} shape is still
+// accepted and applied as given.
+func ReorderSkills(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Categories []struct {
+			Category string   `json:"category"`
+			SkillIDs []string `json:"skillIds"`
+		} `json:"categories"`
+		Skills []struct {
+			ID         string `json:"id"`
+			Category   string `json:"category"`
+			OrderIndex int    `json:"orderIndex"`
+		} `json:"skills"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(input.Categories) == 0 && len(input.Skills) == 0 {
+		http.Error(w, "Categories with ordered skill IDs are required", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("skills")
+
+	opts := options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "orderIndex", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := collection.Find(r.Context(), bson.M{"user": userObjID}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var skills []models.Skill
+	if err = cursor.All(r.Context(), &skills); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	byID := make(map[string]*models.Skill, len(skills))
+	for i := range skills {
+		byID[skills[i].ID.Hex()] = &skills[i]
+	}
+
+	// Work out every skill's final category and position before writing.
+	type placement struct {
+		category   string
+		orderIndex int
+	}
+	placements := make(map[string]placement)
+	listed := make(map[string]bool)
+
+	for _, s := range input.Skills {
+		skill, ok := byID[s.ID]
+		if !ok {
+			http.Error(w, "Skill not found: "+s.ID, http.StatusNotFound)
+			return
+		}
+		if s.OrderIndex < 0 {
+			http.Error(w, "Order index must be at least 0", http.StatusBadRequest)
+			return
+		}
+		category := strings.TrimSpace(s.Category)
+		if category == "" {
+			category = skill.Category
+		}
+		placements[s.ID] = placement{category, s.OrderIndex}
+	}
+
+	for _, c := range input.Categories {
+		category := strings.TrimSpace(c.Category)
+		if category == "" {
+			http.Error(w, "Category is required", http.StatusBadRequest)
+			return
+		}
+		if listed[category] {
+			http.Error(w, "Category listed more than once: "+category, http.StatusBadRequest)
+			return
+		}
+		listed[category] = true
+		for i, id := range c.SkillIDs {
+			if _, ok := byID[id]; !ok {
+				http.Error(w, "Skill not found: "+id, http.StatusNotFound)
+				return
+			}
+			if _, seen := placements[id]; seen {
+				http.Error(w, "Skill listed more than once: "+id, http.StatusBadRequest)
+				return
+			}
+			placements[id] = placement{category, i}
+		}
+	}
+
+	// Skills that stay in a listed category without being listed go after
+	// the listed ones, in their current order.
+	next := make(map[string]int)
+	for _, c := range input.Categories {
+		next[strings.TrimSpace(c.Category)] = len(c.SkillIDs)
+	}
+	for _, skill := range skills {
+		id := skill.ID.Hex()
+		if _, placed := placements[id]; placed || !listed[skill.Category] {
+			continue
+		}
+		placements[id] = placement{skill.Category, next[skill.Category]}
+		next[skill.Category]++
+	}
+
+	names := make(map[string]string)
+	for _, skill := range skills {
+		category := skill.Category
+		if p, ok := placements[skill.ID.Hex()]; ok {
+			category = p.category
+		}
+		key := category + "\x00" + skill.Name
+		if other, clash := names[key]; clash {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "Skill already exists in this category",
+				"skills":  []string{other, skill.ID.Hex()},
+			})
+			return
+		}
+		names[key] = skill.ID.Hex()
+	}
+
+	now := time.Now()
+	var writes []mongo.WriteModel
+	for id, p := range placements {
+		skill := byID[id]
+		if skill.Category == p.category && skill.OrderIndex == p.orderIndex {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": skill.ID, "user": userObjID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"category":   p.category,
+				"orderIndex": p.orderIndex,
+				"updatedAt":  now,
+			}}))
+	}
+
+	modified := int64(0)
+	if len(writes) > 0 {
+		res, err := collection.BulkWrite(r.Context(), writes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		modified = res.ModifiedCount
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"modified": modified,
+		"message":  "Skills reordered successfully",
+	})
+}
+
+// GetSkillCategories merges the categories skills are filed under with the
+// user's own skill categories, so empty categories still show up.
+func GetSkillCategories(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	cursor, err := database.GetCollection("skills").Aggregate(r.Context(), mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user": userObjID}}},
+		{{Key: "$group", Value: bson.M{"_id": "$category", "skillCount": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var counts []struct {
+		Category   string `bson:"_id"`
+		SkillCount int    `bson:"skillCount"`
+	}
+	if err = cursor.All(r.Context(), &counts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cursor, err = database.GetCollection("categories").Find(r.Context(), bson.M{
+		"user": userObjID,
+		"type": models.CategoryTypeSkills,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var userCategories []models.Category
+	if err = cursor.All(r.Context(), &userCategories); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	merged := make(map[string]map[string]interface{})
+	for _, c := range userCategories {
+		merged[c.Name] = map[string]interface{}{
+			"name":       c.Name,
+			"color":      c.Color,
+			"icon":       c.Icon,
+			"skillCount": 0,
+			"custom":     true,
+		}
+	}
+	for _, c := range counts {
+		if c.Category == "" {
+			continue
+		}
+		if entry, ok := merged[c.Category]; ok {
+			entry["skillCount"] = c.SkillCount
+			continue
+		}
+		merged[c.Category] = map[string]interface{}{
+			"name":       c.Category,
+			"skillCount": c.SkillCount,
+			"custom":     false,
+		}
+	}
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		data = append(data, merged[name])
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"count":      len(names),
+		"categories": names,
+		"data":       data,
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Resources and milestones are lists embedded in the skill document, edited
+// through .../skills/:id/<list>/:itemId paths. The helpers below are shared
+// by their handlers.
+
+// loadSkillFromPath finds the authenticated user's skill named in a
+// .../skills/:id<list> path, such as list "/resources".
+func loadSkillFromPath(w http.ResponseWriter, r *http.Request, list string) (*models.Skill, bool) {
+	path := r.URL.Path
+	skillPart := path[:strings.Index(path, list)]
+	id := skillPart[strings.LastIndex(skillPart, "/")+1:]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var skill models.Skill
+	err = database.GetCollection("skills").FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&skill)
+	if err != nil {
+		http.Error(w, "Skill not found", http.StatusNotFound)
+		return nil, false
+	}
+	return &skill, true
+}
+
+// indexByPathID finds the item whose ID is the last segment of the path, or
+// returns -1.
+func indexByPathID[T any](r *http.Request, items []T, id func(T) primitive.ObjectID) int {
+	parts := strings.Split(r.URL.Path, "/")
+	itemID, err := primitive.ObjectIDFromHex(parts[len(parts)-1])
+	if err != nil || itemID.IsZero() {
+		return -1
+	}
+	for i, item := range items {
+		if id(item) == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+// reorderByIDs returns the items in the order of ids, reporting false
+// unless ids lists every item exactly once.
+func reorderByIDs[T any](items []T, ids []string, id func(T) primitive.ObjectID) ([]T, bool) {
+	if len(ids) != len(items) {
+		return nil, false
+	}
+	byID := make(map[string]T, len(items))
+	for _, item := range items {
+		byID[id(item).Hex()] = item
+	}
+	ordered := make([]T, 0, len(items))
+	for _, itemID := range ids {
+		item, ok := byID[itemID]
+		if !ok {
+			return nil, false
+		}
+		delete(byID, itemID)
+		ordered = append(ordered, item)
+	}
+	return ordered, true
+}
+
+// saveSkillLists sets fields on a skill that was read, changed and is now
+// written back whole, such as its resources, stamping updatedAt. The write
+// only goes through while updatedAt is still what was read; otherwise
+// another request changed the skill in between, and rather than overwrite
+// that change it responds 409 so the client can reload. It reports whether
+// the skill was saved.
+func saveSkillLists(w http.ResponseWriter, r *http.Request, skill *models.Skill, set bson.M) bool {
+	filter := bson.M{"_id": skill.ID, "updatedAt": skill.UpdatedAt}
+	if skill.UpdatedAt.IsZero() {
+		filter["updatedAt"] = bson.M{"$in": bson.A{nil, time.Time{}}}
+	}
+	now := time.Now()
+	set["updatedAt"] = now
+
+	res, err := database.GetCollection("skills").UpdateOne(r.Context(), filter, bson.M{"$set": set})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if res.MatchedCount == 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "The skill was changed by another request, reload it and try again",
+		})
+		return false
+	}
+	skill.UpdatedAt = now
+	return true
+}
@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// A skill's standing against its target date and milestones.
+const (
+	deadlineOverdue = "overdue"
+	deadlineAtRisk  = "at-risk"
+	deadlineOnTrack = "on-track"
+)
+
+// atRiskDays is how close a target date has to be before a skill that has
+// not been started, or has no measurable pace yet, counts as at risk.
+const atRiskDays = 14
+
+// milestoneInput is a milestone as clients send it, with a YYYY-MM-DD due date.
+type milestoneInput struct {
+	Title   string `json:"title"`
+	DueDate string `json:"dueDate"`
+}
+
+// toMilestone validates the input and stores its due date as a day in loc.
+func (m milestoneInput) toMilestone(loc *time.Location) (models.SkillMilestone, string) {
+	milestone := models.SkillMilestone{
+		ID:    primitive.NewObjectID(),
+		Title: strings.TrimSpace(m.Title),
+	}
+	if milestone.Title == "" {
+		return milestone, "Milestone title is required"
+	}
+	if m.DueDate != "" {
+		due, err := timeutil.ParseDate(m.DueDate, loc)
+		if err != nil {
+			return milestone, "Invalid milestone due date"
+		}
+		due = timeutil.StartOfDay(due, loc)
+		milestone.DueDate = &due
+	}
+	return milestone, ""
+}
+
+// overdueMilestones counts the incomplete milestones due before today.
+func overdueMilestones(skill models.Skill, today time.Time) int {
+	count := 0
+	for _, milestone := range skill.Milestones {
+		if !milestone.Completed && milestone.DueDate != nil && milestone.DueDate.Before(today) {
+			count++
+		}
+	}
+	return count
+}
+
+// isSkillOverdue reports whether an unfinished skill has passed its target
+// date or left a milestone unmet past its due date.
+func isSkillOverdue(skill models.Skill, today time.Time) bool {
+	if skill.Status == models.SkillStatusCompleted {
+		return false
+	}
+	if skill.TargetDate != nil && skill.TargetDate.Before(today) {
+		return true
+	}
+	return overdueMilestones(skill, today) > 0
+}
+
+// skillDeadlineStatus classifies an unfinished skill with a target date.
+// An in-progress skill is at risk when its estimated completion falls after
+// the target day; without an estimate, or before it has started, it is at
+// risk once the target is within atRiskDays. Skills without a target date,
+// and completed ones, have no status.
+func skillDeadlineStatus(skill models.Skill, estimate map[string]interface{}, today time.Time) string {
+	if isSkillOverdue(skill, today) {
+		return deadlineOverdue
+	}
+	if skill.Status == models.SkillStatusCompleted || skill.TargetDate == nil {
+		return ""
+	}
+	if finish, ok := estimate["estimatedCompletion"].(time.Time); ok {
+		if !finish.Before(skill.TargetDate.AddDate(0, 0, 1)) {
+			return deadlineAtRisk
+		}
+		return deadlineOnTrack
+	}
+	if daysBetween(today, *skill.TargetDate) <= atRiskDays {
+		return deadlineAtRisk
+	}
+	return deadlineOnTrack
+}
+
+// filterSkillsByDeadline keeps the skills whose deadline status is status,
+// estimating in-progress skills from their recorded pace.
+func filterSkillsByDeadline(r *http.Request, userObjID primitive.ObjectID, skills []models.Skill, status string) ([]models.Skill, error) {
+	now := time.Now()
+	today := timeutil.StartOfDay(now, userLocation(r))
+
+	var estimates map[primitive.ObjectID]map[string]interface{}
+	if status == deadlineAtRisk {
+		all, events, err := loadSkillProgress(r.Context(), userObjID)
+		if err != nil {
+			return nil, err
+		}
+		velocities := calculateSkillVelocity(all, events, now)
+		estimates = make(map[primitive.ObjectID]map[string]interface{})
+		for _, skill := range all {
+			if skill.Status == models.SkillStatusInProgress && skill.TargetDate != nil {
+				estimates[skill.ID] = estimateCompletion(skill, measureSkillRate(skill, events[skill.ID], now), velocities[skill.Category], now)
+			}
+		}
+	}
+
+	filtered := []models.Skill{}
+	for _, skill := range skills {
+		if skillDeadlineStatus(skill, estimates[skill.ID], today) == status {
+			filtered = append(filtered, skill)
+		}
+	}
+	return filtered, nil
+}
+
+// deadlineStats reports how completed skills with a target date fared: the
+// share finished by the target day and the average number of days they
+// finished after it, negative when early.
+func deadlineStats(skills []models.Skill, loc *time.Location) (interface{}, interface{}) {
+	count, onTime, slip := 0, 0, 0
+	for _, skill := range skills {
+		if skill.Status != models.SkillStatusCompleted || skill.TargetDate == nil || skill.CompletionDate == nil {
+			continue
+		}
+		days := daysBetween(timeutil.StartOfDay(*skill.TargetDate, loc), timeutil.StartOfDay(*skill.CompletionDate, loc))
+		if days <= 0 {
+			onTime++
+		}
+		slip += days
+		count++
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	return float64(onTime) / float64(count) * 100, float64(slip) / float64(count)
+}
+
+// milestonePatchSchema lists the fields UpdateSkillMilestone accepts.
+var milestonePatchSchema = patch.Schema{
+	"title":     {Kind: patch.String, Check: patch.NotBlank},
+	"dueDate":   {Kind: patch.String, Nullable: true, Check: checkDate},
+	"completed": {Kind: patch.Bool},
+}
+
+// saveSkillMilestones writes the skill's milestones back and responds with
+// status. A skill changed since it was loaded is not overwritten; see
+// saveSkillLists.
+func saveSkillMilestones(w http.ResponseWriter, r *http.Request, skill *models.Skill, status int) {
+	if !saveSkillLists(w, r, skill, bson.M{"milestones": skill.Milestones}) {
+		return
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    skill,
+	})
+}
+
+func milestoneID(milestone models.SkillMilestone) primitive.ObjectID { return milestone.ID }
+
+// AddSkillMilestone appends a milestone to the skill.
+func AddSkillMilestone(w http.ResponseWriter, r *http.Request) {
+	var input milestoneInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	milestone, msg := input.toMilestone(userLocation(r))
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	skill, ok := loadSkillFromPath(w, r, "/milestones")
+	if !ok {
+		return
+	}
+	skill.Milestones = append(skill.Milestones, milestone)
+
+	saveSkillMilestones(w, r, skill, http.StatusCreated)
+}
+
+// UpdateSkillMilestone edits a milestone. Marking it completed stamps the
+// completion time; marking it incomplete again clears it.
+func UpdateSkillMilestone(w http.ResponseWriter, r *http.Request) {
+	changes, err := patch.Decode(r.Body, milestonePatchSchema)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	skill, ok := loadSkillFromPath(w, r, "/milestones")
+	if !ok {
+		return
+	}
+	i := indexByPathID(r, skill.Milestones, milestoneID)
+	if i < 0 {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return
+	}
+
+	milestone := &skill.Milestones[i]
+	if changes.Has("title") {
+		milestone.Title = strings.TrimSpace(changes.Values["title"].(string))
+	}
+	if changes.Has("dueDate") {
+		milestone.DueDate = nil
+		if value, ok := changes.Values["dueDate"].(string); ok {
+			loc := userLocation(r)
+			due, _ := timeutil.ParseDate(value, loc)
+			due = timeutil.StartOfDay(due, loc)
+			milestone.DueDate = &due
+		}
+	}
+	if completed, ok := changes.Values["completed"].(bool); ok && completed != milestone.Completed {
+		milestone.Completed = completed
+		milestone.CompletedAt = nil
+		if completed {
+			now := time.Now()
+			milestone.CompletedAt = &now
+		}
+	}
+
+	saveSkillMilestones(w, r, skill, http.StatusOK)
+}
+
+func DeleteSkillMilestone(w http.ResponseWriter, r *http.Request) {
+	skill, ok := loadSkillFromPath(w, r, "/milestones")
+	if !ok {
+		return
+	}
+	i := indexByPathID(r, skill.Milestones, milestoneID)
+	if i < 0 {
+		http.Error(w, "Milestone not found", http.StatusNotFound)
+		return
+	}
+	skill.Milestones = append(skill.Milestones[:i], skill.Milestones[i+1:]...)
+
+	saveSkillMilestones(w, r, skill, http.StatusOK)
+}
+
+// ReorderSkillMilestones puts the milestones in the order of the given IDs,
+// which must list every milestone exactly once.
+func ReorderSkillMilestones(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		MilestoneIDs []string `json:"milestoneIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	skill, ok := loadSkillFromPath(w, r, "/milestones")
+	if !ok {
+		return
+	}
+	ordered, ok := reorderByIDs(skill.Milestones, input.MilestoneIDs, milestoneID)
+	if !ok {
+		http.Error(w, "Milestone IDs must list every milestone exactly once", http.StatusBadRequest)
+		return
+	}
+	skill.Milestones = ordered
+
+	saveSkillMilestones(w, r, skill, http.StatusOK)
+}
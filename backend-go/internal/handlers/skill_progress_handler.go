@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recordSkillProgress stores a progress event when the status or progress
+// changed between before and after. before is nil for a new skill. The
+// skill itself is already saved, so a failure here is logged rather than
+// failing the request.
+func recordSkillProgress(ctx context.Context, before *models.Skill, after models.Skill) {
+	event := models.SkillProgressEvent{
+		ID:         primitive.NewObjectID(),
+		User:       after.User,
+		Skill:      after.ID,
+		Category:   after.Category,
+		ToStatus:   after.Status,
+		ToProgress: after.Progress,
+		CreatedAt:  time.Now(),
+	}
+	if before != nil {
+		if before.Status == after.Status && before.Progress == after.Progress {
+			return
+		}
+		event.FromStatus = before.Status
+		event.FromProgress = before.Progress
+	}
+
+	if _, err := database.GetCollection("skill_progress_events").InsertOne(ctx, event); err != nil {
+		log.Printf("⚠️ Failed to record progress for skill %s: %v", after.ID.Hex(), err)
+	}
+}
+
+// skillRate is how fast one skill moved: progress points gained per week
+// between starting and finishing it, or until now while it is still open.
+type skillRate struct {
+	gained float64
+	weeks  float64
+}
+
+func (s skillRate) perWeek() float64 {
+	if s.weeks <= 0 {
+		return 0
+	}
+	return s.gained / s.weeks
+}
+
+// measureSkillRate sums the forward progress in the skill's events. Skills
+// created before events were recorded fall back to their current progress.
+func measureSkillRate(skill models.Skill, events []models.SkillProgressEvent, now time.Time) skillRate {
+	start := skill.CreatedAt
+	if skill.StartDate != nil {
+		start = *skill.StartDate
+	} else if len(events) > 0 {
+		start = events[0].CreatedAt
+	}
+	end := now
+	if skill.CompletionDate != nil {
+		end = *skill.CompletionDate
+	}
+
+	gained := 0.0
+	for _, event := range events {
+		if delta := event.ToProgress - event.FromProgress; delta > 0 {
+			gained += float64(delta)
+		}
+	}
+	if len(events) == 0 {
+		gained = float64(skill.Progress)
+	}
+
+	// A skill finished on the day it started still took some time.
+	weeks := math.Max(end.Sub(start).Hours()/(24*7), 1.0/7)
+	return skillRate{gained: gained, weeks: weeks}
+}
+
+type categoryVelocity struct {
+	Category              string   `json:"category"`
+	Completed             int      `json:"completed"`
+	InProgress            int      `json:"inProgress"`
+	AverageDaysToComplete *float64 `json:"averageDaysToComplete"`
+	ProgressPerWeek       *float64 `json:"progressPerWeek"`
+	completionDaysSum     float64
+	completionDaysCount   int
+	rateSum               float64
+	rateCount             int
+}
+
+// estimateCompletion predicts when an in-progress skill reaches 100%, from
+// its own pace when it has one, else from its category's pace, else from how
+// long the category's skills usually take.
+func estimateCompletion(skill models.Skill, rate skillRate, velocity *categoryVelocity, now time.Time) map[string]interface{} {
+	remaining := float64(100 - skill.Progress)
+	estimate := map[string]interface{}{
+		"progressPerWeek":     nil,
+		"estimatedCompletion": nil,
+		"basis":               nil,
+	}
+
+	perWeek, basis := rate.perWeek(), "skill"
+	if skill.Progress == 0 || perWeek <= 0 {
+		perWeek, basis = 0, ""
+		if velocity != nil && velocity.ProgressPerWeek != nil && *velocity.ProgressPerWeek > 0 {
+			perWeek, basis = *velocity.ProgressPerWeek, "category"
+		}
+	}
+	if perWeek > 0 {
+		weeks := remaining / perWeek
+		estimate["progressPerWeek"] = perWeek
+		estimate["estimatedCompletion"] = now.Add(time.Duration(weeks * 7 * 24 * float64(time.Hour)))
+		estimate["basis"] = basis
+		return estimate
+	}
+
+	if velocity != nil && velocity.AverageDaysToComplete != nil && skill.StartDate != nil {
+		finish := skill.StartDate.Add(time.Duration(*velocity.AverageDaysToComplete * 24 * float64(time.Hour)))
+		if finish.Before(now) {
+			finish = now
+		}
+		estimate["estimatedCompletion"] = finish
+		estimate["basis"] = "category-duration"
+	}
+	return estimate
+}
+
+// loadSkillProgress fetches the user's skills and their events grouped per
+// skill in chronological order.
+func loadSkillProgress(ctx context.Context, userObjID primitive.ObjectID) ([]models.Skill, map[primitive.ObjectID][]models.SkillProgressEvent, error) {
+	cursor, err := database.GetCollection("skills").Find(ctx, bson.M{"user": userObjID})
+	if err != nil {
+		return nil, nil, err
+	}
+	var skills []models.Skill
+	if err = cursor.All(ctx, &skills); err != nil {
+		return nil, nil, err
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err = database.GetCollection("skill_progress_events").Find(ctx, bson.M{"user": userObjID}, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var events []models.SkillProgressEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, nil, err
+	}
+
+	bySkill := make(map[primitive.ObjectID][]models.SkillProgressEvent)
+	for _, event := range events {
+		bySkill[event.Skill] = append(bySkill[event.Skill], event)
+	}
+	return skills, bySkill, nil
+}
+
+// calculateSkillVelocity derives per-category velocity from the skills and
+// their events.
+func calculateSkillVelocity(skills []models.Skill, events map[primitive.ObjectID][]models.SkillProgressEvent, now time.Time) map[string]*categoryVelocity {
+	velocities := make(map[string]*categoryVelocity)
+	for _, skill := range skills {
+		v, ok := velocities[skill.Category]
+		if !ok {
+			v = &categoryVelocity{Category: skill.Category}
+			velocities[skill.Category] = v
+		}
+
+		switch skill.Status {
+		case models.SkillStatusCompleted:
+			v.Completed++
+			if skill.StartDate != nil && skill.CompletionDate != nil {
+				v.completionDaysSum += skill.CompletionDate.Sub(*skill.StartDate).Hours() / 24
+				v.completionDaysCount++
+			}
+		case models.SkillStatusInProgress:
+			v.InProgress++
+		default:
+			continue
+		}
+
+		if rate := measureSkillRate(skill, events[skill.ID], now); rate.gained > 0 {
+			v.rateSum += rate.perWeek()
+			v.rateCount++
+		}
+	}
+
+	for _, v := range velocities {
+		if v.completionDaysCount > 0 {
+			days := v.completionDaysSum / float64(v.completionDaysCount)
+			v.AverageDaysToComplete = &days
+		}
+		if v.rateCount > 0 {
+			perWeek := v.rateSum / float64(v.rateCount)
+			v.ProgressPerWeek = &perWeek
+		}
+	}
+	return velocities
+}
+
+// GetSkillTimeline returns a skill's recorded progress changes, oldest
+// first, with its completion estimate when it is in progress.
+func GetSkillTimeline(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	// .../skills/:id/timeline
+	id := parts[len(parts)-2]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	skills, events, err := loadSkillProgress(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var skill *models.Skill
+	for i := range skills {
+		if skills[i].ID == objID {
+			skill = &skills[i]
+			break
+		}
+	}
+	if skill == nil {
+		http.Error(w, "Skill not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	timeline := events[skill.ID]
+	if timeline == nil {
+		timeline = []models.SkillProgressEvent{}
+	}
+
+	var estimate interface{}
+	if skill.Status == models.SkillStatusInProgress {
+		velocities := calculateSkillVelocity(skills, events, now)
+		estimate = estimateCompletion(*skill, measureSkillRate(*skill, timeline, now), velocities[skill.Category], now)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"skill":    skill,
+		"data":     timeline,
+		"estimate": estimate,
+	})
+}
+
+// GetSkillVelocity reports how quickly skills move in each category, and the
+// estimated completion date of every skill still in progress.
+func GetSkillVelocity(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	skills, events, err := loadSkillProgress(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	velocities := calculateSkillVelocity(skills, events, now)
+
+	categories := make([]*categoryVelocity, 0, len(velocities))
+	for _, v := range velocities {
+		categories = append(categories, v)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Category < categories[j].Category
+	})
+
+	estimates := []map[string]interface{}{}
+	for _, skill := range skills {
+		if skill.Status != models.SkillStatusInProgress {
+			continue
+		}
+		estimate := estimateCompletion(skill, measureSkillRate(skill, events[skill.ID], now), velocities[skill.Category], now)
+		estimate["skillId"] = skill.ID
+		estimate["name"] = skill.Name
+		estimate["category"] = skill.Category
+		estimate["progress"] = skill.Progress
+		estimates = append(estimates, estimate)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"categories": categories,
+			"estimates":  estimates,
+		},
+	})
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// normalizeResources gives new resources an ID and a status so they can be
+// addressed individually from then on.
+func normalizeResources(resources []models.Resource) {
+	for i := range resources {
+		if resources[i].ID.IsZero() {
+			resources[i].ID = primitive.NewObjectID()
+		}
+		if resources[i].Status == "" {
+			resources[i].Status = models.ResourceStatusNotStarted
+		}
+	}
+}
+
+// ensureResourceIDs gives a legacy skill's resources IDs and saves them
+// before any are handed out, so an ID a client sees can always be used to
+// address its resource. When another request saved IDs first, the skill is
+// reloaded to use those instead.
+func ensureResourceIDs(ctx context.Context, skill *models.Skill) error {
+	missing := false
+	for _, resource := range skill.Resources {
+		if resource.ID.IsZero() {
+			missing = true
+			break
+		}
+	}
+	if !missing {
+		return nil
+	}
+
+	normalizeResources(skill.Resources)
+	collection := database.GetCollection("skills")
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"_id": skill.ID, "resources": bson.M{"$elemMatch": bson.M{"_id": bson.M{"$exists": false}}}},
+		bson.M{"$set": bson.M{"resources": skill.Resources}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		var saved models.Skill
+		if err := collection.FindOne(ctx, bson.M{"_id": skill.ID}).Decode(&saved); err != nil {
+			return err
+		}
+		*skill = saved
+	}
+	return nil
+}
+
+func checkResourceType(v interface{}) string {
+	return patch.OneOf(
+		string(models.ResourceTypeCourse),
+		string(models.ResourceTypeDocumentation),
+		string(models.ResourceTypeTutorial),
+		string(models.ResourceTypeVideo),
+		string(models.ResourceTypeBook),
+		string(models.ResourceTypeOther),
+	)(v)
+}
+
+func checkResourceStatus(v interface{}) string {
+	return patch.OneOf(
+		string(models.ResourceStatusNotStarted),
+		string(models.ResourceStatusInProgress),
+		string(models.ResourceStatusDone),
+	)(v)
+}
+
+// resourcePatchSchema lists the fields UpdateSkillResource accepts.
+var resourcePatchSchema = patch.Schema{
+	"title":            {Kind: patch.String, Check: patch.NotBlank},
+	"url":              {Kind: patch.String},
+	"type":             {Kind: patch.String, Check: checkResourceType},
+	"status":           {Kind: patch.String, Check: checkResourceStatus},
+	"timeSpentMinutes": {Kind: patch.Integer, Check: patch.AtLeast(0)},
+	"rating":           {Kind: patch.Integer, Nullable: true, Check: patch.Between(models.MinRating, models.MaxRating)},
+}
+
+// loadSkillForResources finds the skill named in a .../skills/:id/resources
+// path, saving IDs for any legacy resources on the way.
+func loadSkillForResources(w http.ResponseWriter, r *http.Request) (*models.Skill, bool) {
+	skill, ok := loadSkillFromPath(w, r, "/resources")
+	if !ok {
+		return nil, false
+	}
+	if err := ensureResourceIDs(r.Context(), skill); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return skill, true
+}
+
+// saveSkillResources writes the skill's resources back, keeping Progress in
+// step when it is derived from them, and responds with status. Derived
+// progress starts an upcoming skill and completes one that reaches 100%;
+// a completed skill keeps its progress. A skill changed since it was loaded
+// is not overwritten; see saveSkillLists.
+func saveSkillResources(w http.ResponseWriter, r *http.Request, skill *models.Skill, status int) {
+	before := *skill
+	set := bson.M{"resources": skill.Resources}
+	derived, err := skill.SyncResourceProgress(time.Now(), userLocation(r))
+	if err != nil {
+		writeSkillStateError(w, err)
+		return
+	}
+	if derived {
+		for key, value := range skillStateFields(*skill) {
+			set[key] = value
+		}
+		set["review"] = skill.Review
+	}
+
+	if !saveSkillLists(w, r, skill, set) {
+		return
+	}
+	recordSkillProgress(r.Context(), &before, *skill)
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    skill,
+	})
+}
+
+func resourceID(resource models.Resource) primitive.ObjectID { return resource.ID }
+
+func AddSkillResource(w http.ResponseWriter, r *http.Request) {
+	var resource models.Resource
+	if err := json.NewDecoder(r.Body).Decode(&resource); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resource.ID = primitive.NilObjectID
+	resource.Title = strings.TrimSpace(resource.Title)
+	if resource.Title == "" && resource.URL == "" {
+		http.Error(w, "Title or URL is required", http.StatusBadRequest)
+		return
+	}
+	if resource.Type == "" {
+		resource.Type = models.ResourceTypeOther
+	}
+	if msg := checkResourceType(string(resource.Type)); msg != "" {
+		http.Error(w, "Type "+msg, http.StatusBadRequest)
+		return
+	}
+	if resource.Status != "" {
+		if msg := checkResourceStatus(string(resource.Status)); msg != "" {
+			http.Error(w, "Status "+msg, http.StatusBadRequest)
+			return
+		}
+	}
+	if resource.TimeSpentMinutes < 0 {
+		http.Error(w, "Time spent cannot be negative", http.StatusBadRequest)
+		return
+	}
+	if resource.Rating != nil && (*resource.Rating < models.MinRating || *resource.Rating > models.MaxRating) {
+		http.Error(w, "Rating must be between 1 and 5", http.StatusBadRequest)
+		return
+	}
+
+	skill, ok := loadSkillForResources(w, r)
+	if !ok {
+		return
+	}
+	resources := []models.Resource{resource}
+	normalizeResources(resources)
+	skill.Resources = append(skill.Resources, resources[0])
+
+	saveSkillResources(w, r, skill, http.StatusCreated)
+}
+
+func UpdateSkillResource(w http.ResponseWriter, r *http.Request) {
+	changes, err := patch.Decode(r.Body, resourcePatchSchema)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+	if changes.Has("title") {
+		changes.Values["title"] = strings.TrimSpace(changes.Values["title"].(string))
+	}
+
+	skill, ok := loadSkillForResources(w, r)
+	if !ok {
+		return
+	}
+	i := indexByPathID(r, skill.Resources, resourceID)
+	if i < 0 {
+		http.Error(w, "Resource not found", http.StatusNotFound)
+		return
+	}
+
+	id := skill.Resources[i].ID
+	if err := changes.Apply(&skill.Resources[i]); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	skill.Resources[i].ID = id
+
+	saveSkillResources(w, r, skill, http.StatusOK)
+}
+
+func DeleteSkillResource(w http.ResponseWriter, r *http.Request) {
+	skill, ok := loadSkillForResources(w, r)
+	if !ok {
+		return
+	}
+	i := indexByPathID(r, skill.Resources, resourceID)
+	if i < 0 {
+		http.Error(w, "Resource not found", http.StatusNotFound)
+		return
+	}
+	skill.Resources = append(skill.Resources[:i], skill.Resources[i+1:]...)
+
+	saveSkillResources(w, r, skill, http.StatusOK)
+}
+
+// ReorderSkillResources puts the resources in the order of the given IDs,
+// which must list every resource exactly once.
+func ReorderSkillResources(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ResourceIDs []string `json:"resourceIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	skill, ok := loadSkillForResources(w, r)
+	if !ok {
+		return
+	}
+	ordered, ok := reorderByIDs(skill.Resources, input.ResourceIDs, resourceID)
+	if !ok {
+		http.Error(w, "Resource IDs must list every resource exactly once", http.StatusBadRequest)
+		return
+	}
+	skill.Resources = ordered
+
+	saveSkillResources(w, r, skill, http.StatusOK)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxReviewLookaheadDays caps how far ahead GetDueSkillReviews looks.
+const maxReviewLookaheadDays = 365
+
+// isReviewDue reports whether a skill's next review falls on or before day.
+func isReviewDue(skill models.Skill, day time.Time) bool {
+	return skill.ReviewEnabled && skill.Status == models.SkillStatusCompleted &&
+		skill.Review != nil && !skill.Review.NextReview.After(day)
+}
+
+// GetDueSkillReviews lists completed skills whose review is due today or
+// earlier, most overdue first. days=N also includes reviews due in the next
+// N days.
+func GetDueSkillReviews(w http.ResponseWriter, r *http.Request) {
+	loc := userLocation(r)
+	today := timeutil.StartOfDay(time.Now(), loc)
+
+	days := 0
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 0 || parsed > maxReviewLookaheadDays {
+			http.Error(w, "Days must be a number between 0 and 365", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+	until := today.AddDate(0, 0, days)
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	opts := options.Find().SetSort(bson.D{{Key: "review.nextReview", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := database.GetCollection("skills").Find(r.Context(), bson.M{
+		"user":              userObjID,
+		"status":            models.SkillStatusCompleted,
+		"reviewEnabled":     true,
+		"review.nextReview": bson.M{"$lte": until},
+	}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var skills []models.Skill
+	if err = cursor.All(r.Context(), &skills); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := make([]map[string]interface{}, 0, len(skills))
+	overdue := 0
+	for _, skill := range skills {
+		overdueDays := daysBetween(skill.Review.NextReview, today)
+		if overdueDays > 0 {
+			overdue++
+		}
+		data = append(data, map[string]interface{}{
+			"skill":       skill,
+			"nextReview":  skill.Review.NextReview,
+			"overdueDays": overdueDays,
+			"due":         isReviewDue(skill, today),
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(data),
+		"overdue": overdue,
+		"data":    data,
+	})
+}
+
+// SubmitSkillReview records a review of a completed skill with a recall
+// quality from 0 to 5 and schedules the next one. Reviewing early is allowed
+// and schedules from today.
+func SubmitSkillReview(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	// .../skills/:id/review
+	id := parts[len(parts)-2]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var input struct {
+		Quality *int `json:"quality"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Quality == nil || *input.Quality < models.MinRecallQuality || *input.Quality > models.MaxRecallQuality {
+		http.Error(w, "Quality must be between 0 and 5", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("skills")
+
+	var skill models.Skill
+	if err := collection.FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&skill); err != nil {
+		http.Error(w, "Skill not found", http.StatusNotFound)
+		return
+	}
+	if skill.Status != models.SkillStatusCompleted {
+		http.Error(w, "Only completed skills can be reviewed", http.StatusBadRequest)
+		return
+	}
+	if !skill.ReviewEnabled {
+		http.Error(w, "Reviews are not enabled for this skill", http.StatusBadRequest)
+		return
+	}
+
+	loc := userLocation(r)
+	review, _ := models.ReviewFor(skill, skill.Status, true, loc)
+	review.Record(*input.Quality, timeutil.StartOfDay(time.Now(), loc))
+	skill.Review = review
+
+	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"review": review, "updatedAt": time.Now()}})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	event := models.SkillReviewEvent{
+		ID:           primitive.NewObjectID(),
+		User:         userObjID,
+		Skill:        objID,
+		Quality:      *input.Quality,
+		IntervalDays: review.IntervalDays,
+		EaseFactor:   review.EaseFactor,
+		NextReview:   review.NextReview,
+		CreatedAt:    time.Now(),
+	}
+	if _, err := database.GetCollection("skill_reviews").InsertOne(r.Context(), event); err != nil {
+		log.Printf("⚠️ Failed to record review for skill %s: %v", objID.Hex(), err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    skill,
+		"review":  event,
+	})
+}
+
+// GetSkillReviews lists a skill's past reviews, oldest first.
+func GetSkillReviews(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	// .../skills/:id/review
+	id := parts[len(parts)-2]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var skill models.Skill
+	if err := database.GetCollection("skills").FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&skill); err != nil {
+		http.Error(w, "Skill not found", http.StatusNotFound)
+		return
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := database.GetCollection("skill_reviews").Find(r.Context(), bson.M{"user": userObjID, "skill": objID}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reviews := []models.SkillReviewEvent{}
+	if err = cursor.All(r.Context(), &reviews); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"skill":   skill,
+		"count":   len(reviews),
+		"data":    reviews,
+	})
+}
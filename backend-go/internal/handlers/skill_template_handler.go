@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/roadmap"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportSkillTemplate imports a roadmap template into the user's skills.
+// The template is either the raw request body, when it is sent as YAML, or
+// a JSON body of {format, content, dryRun} with content holding the file,
+// or {template, dryRun} with the template inline. Importing is idempotent;
+// see roadmap.Import for how existing skills are merged.
+func ImportSkillTemplate(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+	if err != nil {
+		http.Error(w, "Template is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var template *roadmap.Template
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+		template, err = roadmap.Parse(body, roadmap.FormatYAML)
+	} else {
+		var input struct {
+			Format   string            `json:"format"`
+			Content  string            `json:"content"`
+			Template *roadmap.Template `json:"template"`
+			DryRun   bool              `json:"dryRun"`
+		}
+		if err := json.Unmarshal(body, &input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dryRun = dryRun || input.DryRun
+		switch {
+		case input.Template != nil:
+			template = input.Template
+			err = template.Validate()
+		case input.Content != "":
+			if input.Format == "" {
+				input.Format = roadmap.FormatYAML
+			}
+			template, err = roadmap.Parse([]byte(input.Content), input.Format)
+		default:
+			http.Error(w, "Template or content is required", http.StatusBadRequest)
+			return
+		}
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	report, err := roadmap.Import(r.Context(), userObjID, template, userLocation(r), dryRun)
+	if err != nil {
+		if errors.Is(err, roadmap.ErrPrerequisiteCycle) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// ExportSkillTemplate downloads the user's skills as a roadmap template that
+// ImportSkillTemplate, or the import-roadmap command, can read back.
+func ExportSkillTemplate(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = roadmap.FormatYAML
+	}
+	if format != roadmap.FormatYAML && format != roadmap.FormatJSON {
+		http.Error(w, "Format must be yaml or json", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
+	if name == "" {
+		name = "My roadmap"
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	template, err := roadmap.Export(r.Context(), userObjID, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := "roadmap-" + time.Now().In(userLocation(r)).Format(timeutil.DateLayout) + "." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == roadmap.FormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	roadmap.Encode(w, template, format)
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func checkObjectID(v interface{}) string {
+	if _, err := primitive.ObjectIDFromHex(v.(string)); err != nil {
+		return "must be a valid ID"
+	}
+	return ""
+}
+
+// loadLinkedSkills fetches the skills that entries, items or activities are
+// being linked to, responding with 404 when one of them is not the user's.
+func loadLinkedSkills(w http.ResponseWriter, r *http.Request, userObjID primitive.ObjectID, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.Skill, bool) {
+	skills := make(map[primitive.ObjectID]*models.Skill)
+	if len(ids) == 0 {
+		return skills, true
+	}
+	cursor, err := database.GetCollection("skills").Find(r.Context(), bson.M{"_id": bson.M{"$in": ids}, "user": userObjID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	var found []models.Skill
+	if err = cursor.All(r.Context(), &found); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	for i := range found {
+		skills[found[i].ID] = &found[i]
+	}
+	for _, id := range ids {
+		if _, ok := skills[id]; !ok {
+			http.Error(w, "Skill not found: "+id.Hex(), http.StatusNotFound)
+			return nil, false
+		}
+	}
+	return skills, true
+}
+
+// itemSkillIDs lists the skills schedule items are linked to.
+func itemSkillIDs(items []models.ScheduleItem) []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, item := range items {
+		if item.SkillID != nil {
+			ids = append(ids, *item.SkillID)
+		}
+	}
+	return ids
+}
+
+// activitySkillIDs lists the skills timetable activities are linked to.
+func activitySkillIDs(activities []models.Activity) []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, activity := range activities {
+		if activity.SkillID != nil {
+			ids = append(ids, *activity.SkillID)
+		}
+	}
+	return ids
+}
+
+// completedItemSkillIDs lists the skills of completed items, the point at
+// which a schedule item counts as time spent.
+func completedItemSkillIDs(items []models.ScheduleItem) []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, item := range items {
+		if item.Completed && item.SkillID != nil {
+			ids = append(ids, *item.SkillID)
+		}
+	}
+	return ids
+}
+
+// startPracticedSkills moves the given skills to in-progress if they opted
+// in with AutoStart and are still upcoming. The time that triggered it is
+// already saved, so failures are logged rather than failing the request.
+func startPracticedSkills(ctx context.Context, userObjID primitive.ObjectID, ids []primitive.ObjectID) {
+	if len(ids) == 0 {
+		return
+	}
+	collection := database.GetCollection("skills")
+	cursor, err := collection.Find(ctx, bson.M{
+		"_id":       bson.M{"$in": ids},
+		"user":      userObjID,
+		"autoStart": true,
+		"status":    models.SkillStatusUpcoming,
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to auto-start skills: %v", err)
+		return
+	}
+	var skills []models.Skill
+	if err = cursor.All(ctx, &skills); err != nil {
+		log.Printf("⚠️ Failed to auto-start skills: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, skill := range skills {
+		before := skill
+		// Matching on status again keeps two requests logging time at once
+		// from recording the start twice.
+		res, err := collection.UpdateOne(ctx,
+			bson.M{"_id": skill.ID, "status": models.SkillStatusUpcoming},
+			bson.M{"$set": bson.M{"status": models.SkillStatusInProgress, "startDate": now, "updatedAt": now}},
+		)
+		if err != nil {
+			log.Printf("⚠️ Failed to auto-start skill %s: %v", skill.ID.Hex(), err)
+			continue
+		}
+		if res.ModifiedCount == 0 {
+			continue
+		}
+		skill.Status = models.SkillStatusInProgress
+		skill.StartDate = &now
+		recordSkillProgress(ctx, &before, skill)
+	}
+}
+
+// unlinkSkill removes a deleted skill from the entries, items and activities
+// that were linked to it. Focus sessions keep the ID as a record of what they
+// were for.
+func unlinkSkill(ctx context.Context, userObjID, skillID primitive.ObjectID) {
+	unset := func(collection string, filter bson.M, fields bson.M, arrayFilters ...interface{}) {
+		filter["user"] = userObjID
+		opts := options.Update()
+		if len(arrayFilters) > 0 {
+			opts.SetArrayFilters(options.ArrayFilters{Filters: arrayFilters})
+		}
+		if _, err := database.GetCollection(collection).UpdateMany(ctx, filter, bson.M{"$unset": fields}, opts); err != nil {
+			log.Printf("⚠️ Failed to unlink skill %s from %s: %v", skillID.Hex(), collection, err)
+		}
+	}
+	unset("workinghours", bson.M{"skillId": skillID}, bson.M{"skillId": ""})
+	unset("schedules", bson.M{"items.skillId": skillID}, bson.M{"items.$[i].skillId": ""},
+		bson.M{"i.skillId": skillID})
+	unset("timetables", bson.M{"defaultActivities.skillId": skillID}, bson.M{"defaultActivities.$[a].skillId": ""},
+		bson.M{"a.skillId": skillID})
+	unset("timetables", bson.M{"currentWeek.activities.activity.skillId": skillID}, bson.M{"currentWeek.activities.$[p].activity.skillId": ""},
+		bson.M{"p.activity.skillId": skillID})
+	unset("timetables", bson.M{"history.activities.activity.skillId": skillID}, bson.M{"history.$[].activities.$[p].activity.skillId": ""},
+		bson.M{"p.activity.skillId": skillID})
+}
+
+// activityHours reads a timetable activity's "HH:MM-HH:MM" time, which may
+// run past midnight. Activities without a parseable window still count as a
+// session, just without hours.
+func activityHours(activity models.Activity) float64 {
+	startTime, endTime, ok := strings.Cut(activity.Time, "-")
+	if !ok {
+		return 0
+	}
+	start, ok := models.ClockMinutes(startTime)
+	if !ok {
+		return 0
+	}
+	end, ok := models.ClockMinutes(endTime)
+	if !ok {
+		return 0
+	}
+	if end < start {
+		end += models.MinutesPerDay
+	}
+	return float64(end-start) / 60
+}
+
+type skillTimeSource struct {
+	Hours    float64 `json:"hours"`
+	Sessions int     `json:"sessions"`
+}
+
+type skillTime struct {
+	SkillID       primitive.ObjectID          `json:"skillId"`
+	Name          string                      `json:"name"`
+	Category      string                      `json:"category"`
+	Status        models.SkillStatus          `json:"status"`
+	Hours         float64                     `json:"hours"`
+	Sessions      int                         `json:"sessions"`
+	LastPracticed *time.Time                  `json:"lastPracticed"`
+	Sources       map[string]*skillTimeSource `json:"sources"`
+}
+
+func (t *skillTime) add(source string, hours float64, day time.Time) {
+	s := t.Sources[source]
+	s.Hours += hours
+	s.Sessions++
+	t.Hours += hours
+	t.Sessions++
+	if t.LastPracticed == nil || day.After(*t.LastPracticed) {
+		t.LastPracticed = &day
+	}
+}
+
+// GetSkillTimeInvested totals the time linked to each skill: working-hours
+// entries, completed schedule items, checked timetable activities and focus
+// sessions. Each one counts as a session. Completed focus sessions already
+// add their minutes to the day's working hours, so their hours are only
+// counted here when that entry is not linked to the same skill.
+func GetSkillTimeInvested(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	loc := userLocation(r)
+
+	byID, err := fetchSkillsByID(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if skillID := r.URL.Query().Get("skillId"); skillID != "" {
+		objID, err := primitive.ObjectIDFromHex(skillID)
+		if err != nil {
+			http.Error(w, "Invalid skill ID", http.StatusBadRequest)
+			return
+		}
+		skill, ok := byID[objID]
+		if !ok {
+			http.Error(w, "Skill not found", http.StatusNotFound)
+			return
+		}
+		byID = map[primitive.ObjectID]*models.Skill{objID: skill}
+	}
+
+	totals := make(map[primitive.ObjectID]*skillTime, len(byID))
+	for id, skill := range byID {
+		totals[id] = &skillTime{
+			SkillID:  id,
+			Name:     skill.Name,
+			Category: skill.Category,
+			Status:   skill.Status,
+			Sources: map[string]*skillTimeSource{
+				"workingHours": {},
+				"schedule":     {},
+				"timetable":    {},
+				"focus":        {},
+			},
+		}
+	}
+	cursor, err := database.GetCollection("workinghours").Find(r.Context(), bson.M{"user": userObjID, "skillId": bson.M{"$type": "objectId"}})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var entries []models.WorkingHours
+	if err = cursor.All(r.Context(), &entries); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	type focusKey struct {
+		day      string
+		category string
+		skill    primitive.ObjectID
+	}
+	entryFor := make(map[focusKey]bool)
+	for _, wh := range entries {
+		t, ok := totals[*wh.SkillID]
+		if !ok {
+			continue
+		}
+		entryFor[focusKey{wh.Date.In(loc).Format(timeutil.DateLayout), wh.Category, *wh.SkillID}] = true
+		if wh.AchievedHours > 0 {
+			t.add("workingHours", wh.AchievedHours, timeutil.StartOfDay(wh.Date, loc))
+		}
+	}
+
+	opts := options.Find().SetProjection(bson.M{"date": 1, "items": 1})
+	cursor, err = database.GetCollection("schedules").Find(r.Context(), bson.M{"user": userObjID, "items.skillId": bson.M{"$type": "objectId"}}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var schedules []models.Schedule
+	if err = cursor.All(r.Context(), &schedules); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, schedule := range schedules {
+		for _, item := range schedule.Items {
+			if !item.Completed || item.SkillID == nil {
+				continue
+			}
+			if t, ok := totals[*item.SkillID]; ok {
+				hours := 0.0
+				if start, end, err := item.Window(); err == nil {
+					hours = float64(end-start) / 60
+				}
+				t.add("schedule", hours, timeutil.StartOfDay(schedule.Date, loc))
+			}
+		}
+	}
+
+	opts = options.Find().SetProjection(bson.M{"currentWeek": 1, "history": 1})
+	cursor, err = database.GetCollection("timetables").Find(r.Context(), bson.M{"user": userObjID}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var timetables []models.Timetable
+	if err = cursor.All(r.Context(), &timetables); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, timetable := range timetables {
+		for _, week := range append(timetable.History, timetable.CurrentWeek) {
+			if week.WeekStartDate.IsZero() {
+				continue
+			}
+			monday := timeutil.StartOfDay(week.WeekStartDate, loc)
+			for _, progress := range week.Activities {
+				if progress.Activity.SkillID == nil {
+					continue
+				}
+				t, ok := totals[*progress.Activity.SkillID]
+				if !ok {
+					continue
+				}
+				for i, done := range progress.DailyStatus {
+					if done {
+						t.add("timetable", activityHours(progress.Activity), monday.AddDate(0, 0, i))
+					}
+				}
+			}
+		}
+	}
+
+	cursor, err = database.GetCollection("focussessions").Find(r.Context(), bson.M{
+		"user":    userObjID,
+		"skillId": bson.M{"$type": "objectId"},
+		"status":  bson.M{"$ne": models.FocusSessionActive},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var sessions []models.FocusSession
+	if err = cursor.All(r.Context(), &sessions); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, session := range sessions {
+		t, ok := totals[*session.SkillID]
+		if !ok || session.FocusedMinutes <= 0 {
+			continue
+		}
+		hours := session.FocusedMinutes / 60
+		key := focusKey{session.Date.In(loc).Format(timeutil.DateLayout), session.Category, *session.SkillID}
+		if session.Status == models.FocusSessionCompleted && entryFor[key] {
+			hours = 0
+		}
+		t.add("focus", hours, timeutil.StartOfDay(session.Date, loc))
+	}
+
+	data := make([]*skillTime, 0, len(totals))
+	for _, t := range totals {
+		data = append(data, t)
+	}
+	sort.Slice(data, func(i, j int) bool {
+		if data[i].Hours != data[j].Hours {
+			return data[i].Hours > data[j].Hours
+		}
+		return lessSkill(byID[data[i].SkillID], byID[data[j].SkillID])
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(data),
+		"data":    data,
+	})
+}
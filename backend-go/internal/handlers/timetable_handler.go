@@ -11,27 +11,22 @@ import (
 	"service-exchange-backend-go/internal/auth"
 	"service-exchange-backend-go/internal/database"
 	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func startNewWeek(timetable *models.Timetable) {
+func startNewWeek(timetable *models.Timetable, loc *time.Location) {
 	if len(timetable.CurrentWeek.Activities) > 0 {
 		timetable.History = append(timetable.History, timetable.CurrentWeek)
 	}
 
 	now := time.Now()
-	offset := int(now.Weekday())
-	if offset == 0 {
-		offset = 6
-	} else {
-		offset = offset - 1
-	}
-
-	monday := time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, now.Location())
-	sunday := monday.Add(6 * 24 * time.Hour).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+	monday := timeutil.StartOfWeek(now, loc)
+	sunday := timeutil.EndOfWeek(now, loc)
 
 	newActivities := []models.DailyProgress{}
 	for _, act := range timetable.DefaultActivities {
@@ -51,6 +46,25 @@ func startNewWeek(timetable *models.Timetable) {
 	}
 }
 
+// assignActivityIDs gives each activity a stable ID. An activity without one
+// keeps the ID of the previous activity with the same name, time and
+// category, so calendar feeds see an edit rather than a new event.
+func assignActivityIDs(activities, previous []models.Activity) {
+	for i := range activities {
+		if !activities[i].ID.IsZero() {
+			continue
+		}
+		activities[i].ID = primitive.NewObjectID()
+		for _, old := range previous {
+			if !old.ID.IsZero() && old.Name == activities[i].Name &&
+				old.Time == activities[i].Time && old.Category == activities[i].Category {
+				activities[i].ID = old.ID
+				break
+			}
+		}
+	}
+}
+
 func calculateTimetableStats(timetable *models.Timetable) {
 	if len(timetable.CurrentWeek.Activities) > 0 {
 		totalPossible := float64(len(timetable.CurrentWeek.Activities) * 7)
@@ -143,6 +157,10 @@ func CreateTimetable(w http.ResponseWriter, r *http.Request) {
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	collection := database.GetCollection("timetables")
 
+	if _, ok := loadLinkedSkills(w, r, userObjID, activitySkillIDs(input.DefaultActivities)); !ok {
+		return
+	}
+
 	count, _ := collection.CountDocuments(r.Context(), bson.M{
 		"user": userObjID,
 		"name": strings.TrimSpace(input.Name),
@@ -174,8 +192,9 @@ func CreateTimetable(w http.ResponseWriter, r *http.Request) {
 	if timetable.DefaultActivities == nil {
 		timetable.DefaultActivities = []models.Activity{}
 	}
+	assignActivityIDs(timetable.DefaultActivities, nil)
 
-	startNewWeek(&timetable)
+	startNewWeek(&timetable, userLocation(r))
 	calculateTimetableStats(&timetable)
 
 	if timetable.IsActive {
@@ -225,6 +244,14 @@ func GetTimetable(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// timetablePatchSchema lists the fields UpdateTimetable accepts. Activities
+// and weeks have their own endpoints.
+var timetablePatchSchema = patch.Schema{
+	"name":        {Kind: patch.String, Check: patch.NotBlank},
+	"description": {Kind: patch.String, Nullable: true},
+	"isActive":    {Kind: patch.Bool},
+}
+
 func UpdateTimetable(w http.ResponseWriter, r *http.Request) {
 	setCacheHeaders(w)
 	parts := strings.Split(r.URL.Path, "/")
@@ -235,13 +262,9 @@ func UpdateTimetable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input struct {
-		Name        *string `json:"name"`
-		Description *string `json:"description"`
-		IsActive    *bool   `json:"isActive"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	changes, err := patch.Decode(r.Body, timetablePatchSchema)
+	if err != nil {
+		writePatchError(w, err)
 		return
 	}
 
@@ -256,49 +279,44 @@ func UpdateTimetable(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	update := bson.M{"updatedAt": time.Now()}
-
-	if input.Name != nil && *input.Name != timetable.Name {
-		count, _ := collection.CountDocuments(r.Context(), bson.M{
-			"user": userObjID,
-			"name": strings.TrimSpace(*input.Name),
-			"_id":  bson.M{"$ne": objID},
-		})
-		if count > 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": false,
-				"message": "A timetable with this name already exists",
+	if name, ok := changes.Values["name"].(string); ok {
+		name = strings.TrimSpace(name)
+		changes.Values["name"] = name
+		if name != timetable.Name {
+			count, _ := collection.CountDocuments(r.Context(), bson.M{
+				"user": userObjID,
+				"name": name,
+				"_id":  bson.M{"$ne": objID},
 			})
-			return
+			if count > 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success": false,
+					"message": "A timetable with this name already exists",
+				})
+				return
+			}
 		}
-		update["name"] = strings.TrimSpace(*input.Name)
-	}
-
-	if input.Description != nil {
-		update["description"] = *input.Description
 	}
 
-	if input.IsActive != nil {
-		update["isActive"] = *input.IsActive
-		if *input.IsActive {
-			collection.UpdateMany(r.Context(),
-				bson.M{"user": userObjID, "_id": bson.M{"$ne": objID}},
-				bson.M{"$set": bson.M{"isActive": false}},
-			)
-		}
+	if isActive, _ := changes.Values["isActive"].(bool); isActive {
+		collection.UpdateMany(r.Context(),
+			bson.M{"user": userObjID, "_id": bson.M{"$ne": objID}},
+			bson.M{"$set": bson.M{"isActive": false}},
+		)
 	}
 
-	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID}, bson.M{"$set": update})
+	_, err = collection.UpdateOne(r.Context(), bson.M{"_id": objID}, changes.Update(bson.M{"updatedAt": time.Now()}))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&timetable)
+	var updated models.Timetable
+	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&updated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"data":    timetable,
+		"data":    updated,
 	})
 }
 
@@ -386,7 +404,8 @@ func GetCurrentWeek(w http.ResponseWriter, r *http.Request) {
 					CreatedAt: time.Now(),
 					UpdatedAt: time.Now(),
 				}
-				startNewWeek(&timetable)
+				assignActivityIDs(timetable.DefaultActivities, nil)
+				startNewWeek(&timetable, userLocation(r))
 				collection.InsertOne(r.Context(), timetable)
 				err = nil
 			} else {
@@ -403,7 +422,7 @@ func GetCurrentWeek(w http.ResponseWriter, r *http.Request) {
 
 	now := time.Now()
 	if !timetable.CurrentWeek.WeekEndDate.IsZero() && now.After(timetable.CurrentWeek.WeekEndDate) {
-		startNewWeek(&timetable)
+		startNewWeek(&timetable, userLocation(r))
 		calculateTimetableStats(&timetable)
 		timetable.UpdatedAt = time.Now()
 		collection.UpdateOne(r.Context(), bson.M{"_id": timetable.ID}, bson.M{"$set": timetable})
@@ -456,16 +475,16 @@ func ToggleActivityStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	found := false
+	var toggled *models.DailyProgress
 	for i, activity := range timetable.CurrentWeek.Activities {
 		if activity.ID.Hex() == input.ActivityID {
 			timetable.CurrentWeek.Activities[i].DailyStatus[input.DayIndex] = !timetable.CurrentWeek.Activities[i].DailyStatus[input.DayIndex]
-			found = true
+			toggled = &timetable.CurrentWeek.Activities[i]
 			break
 		}
 	}
 
-	if !found {
+	if toggled == nil {
 		http.Error(w, "Activity not found", http.StatusNotFound)
 		return
 	}
@@ -478,6 +497,9 @@ func ToggleActivityStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if toggled.DailyStatus[input.DayIndex] && toggled.Activity.SkillID != nil {
+		startPracticedSkills(r.Context(), userObjID, []primitive.ObjectID{*toggled.Activity.SkillID})
+	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -507,6 +529,10 @@ func UpdateDefaultActivities(w http.ResponseWriter, r *http.Request) {
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	collection := database.GetCollection("timetables")
 
+	if _, ok := loadLinkedSkills(w, r, userObjID, activitySkillIDs(input.Activities)); !ok {
+		return
+	}
+
 	var timetable models.Timetable
 	err = collection.FindOne(r.Context(), bson.M{"_id": objID, "user": userObjID}).Decode(&timetable)
 	if err != nil {
@@ -514,6 +540,7 @@ func UpdateDefaultActivities(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	assignActivityIDs(input.Activities, timetable.DefaultActivities)
 	timetable.DefaultActivities = input.Activities
 
 	newCurrentActivities := []models.DailyProgress{}
@@ -691,7 +718,7 @@ func StartNewWeekHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	startNewWeek(&timetable)
+	startNewWeek(&timetable, userLocation(r))
 	collection.UpdateOne(r.Context(), bson.M{"_id": objID}, bson.M{"$set": timetable})
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
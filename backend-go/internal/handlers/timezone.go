@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type locationCacheKey struct{}
+
+type locationCache struct {
+	once sync.Once
+	loc  *time.Location
+}
+
+// CacheUserLocation gives each request a place to keep the user's timezone,
+// so userLocation reads the user document at most once per request however
+// many helpers ask for it.
+func CacheUserLocation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), locationCacheKey{}, &locationCache{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// userLocation returns the timezone preference of the authenticated user.
+// Day and week boundaries are always cut in this location, never in the
+// server's own zone. Users without a preference are treated as UTC.
+func userLocation(r *http.Request) *time.Location {
+	cache, ok := r.Context().Value(locationCacheKey{}).(*locationCache)
+	if !ok {
+		return lookupUserLocation(r)
+	}
+	cache.once.Do(func() { cache.loc = lookupUserLocation(r) })
+	return cache.loc
+}
+
+func lookupUserLocation(r *http.Request) *time.Location {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"timezone": 1})
+	if err := database.GetCollection("users").FindOne(r.Context(), bson.M{"_id": userObjID}, opts).Decode(&user); err != nil {
+		return time.UTC
+	}
+	return timeutil.Location(user.Timezone)
+}
+
+// dateRangeQuery builds a date filter covering whole calendar days in loc,
+// from the start of startDateStr up to the end of endDateStr. It returns nil
+// when either bound is missing or cannot be parsed.
+func dateRangeQuery(startDateStr, endDateStr string, loc *time.Location) bson.M {
+	if startDateStr == "" || endDateStr == "" {
+		return nil
+	}
+	startDate, err := timeutil.ParseDate(startDateStr, loc)
+	if err != nil {
+		return nil
+	}
+	endDate, err := timeutil.ParseDate(endDateStr, loc)
+	if err != nil {
+		return nil
+	}
+	return bson.M{
+		"$gte": timeutil.StartOfDay(startDate, loc),
+		"$lt":  timeutil.StartOfDay(endDate, loc).AddDate(0, 0, 1),
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"service-exchange-backend-go/internal/patch"
+)
+
+// writePatchError reports a rejected merge patch, listing each invalid field
+// when the patch failed validation.
+func writePatchError(w http.ResponseWriter, err error) {
+	var validationErr *patch.ValidationError
+	if !errors.As(err, &validationErr) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": false,
+		"message": "Validation failed",
+		"errors":  validationErr.Errors,
+	})
+}
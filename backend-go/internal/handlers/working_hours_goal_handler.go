@@ -0,0 +1,354 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// goalPeriodStart returns the start of the period containing t, cut in t's
+// own location.
+func goalPeriodStart(period models.GoalPeriod, t time.Time) time.Time {
+	switch period {
+	case models.GoalPeriodWeekly:
+		return timeutil.StartOfWeek(t, t.Location())
+	case models.GoalPeriodMonthly:
+		y, m, _ := t.Date()
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return timeutil.StartOfDay(t, t.Location())
+	}
+}
+
+func goalPeriodEnd(period models.GoalPeriod, start time.Time) time.Time {
+	switch period {
+	case models.GoalPeriodWeekly:
+		return start.AddDate(0, 0, 7)
+	case models.GoalPeriodMonthly:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+func daysBetween(start, end time.Time) int {
+	return int(math.Round(end.Sub(start).Hours() / 24))
+}
+
+// calculateStreaks walks consecutive periods from first up to current and
+// reports the longest run of met periods and the run ending at current. An
+// unmet current period does not break the streak since it is still open.
+func calculateStreaks(period models.GoalPeriod, first, current time.Time, met func(time.Time) bool) (int, int) {
+	longest, run := 0, 0
+	for p := first; !p.After(current); p = goalPeriodEnd(period, p) {
+		if met(p) {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else if !p.Equal(current) {
+			run = 0
+		}
+	}
+	return run, longest
+}
+
+func fetchAllWorkingHours(ctx context.Context, userObjID primitive.ObjectID) ([]models.WorkingHours, error) {
+	collection := database.GetCollection("workinghours")
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	cursor, err := collection.Find(ctx, bson.M{"user": userObjID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var workingHours []models.WorkingHours
+	if err = cursor.All(ctx, &workingHours); err != nil {
+		return nil, err
+	}
+	return workingHours, nil
+}
+
+func calculateGoalProgress(goal models.WorkingHoursGoal, workingHours []models.WorkingHours, now time.Time) map[string]interface{} {
+	today := timeutil.StartOfDay(now, now.Location())
+	start := goalPeriodStart(goal.Period, today)
+	end := goalPeriodEnd(goal.Period, start)
+
+	periodTotals := make(map[time.Time]float64)
+	var first time.Time
+	var achievedInPeriod, achievedToday float64
+
+	for _, wh := range workingHours {
+		if goal.Category != "" && wh.Category != goal.Category {
+			continue
+		}
+		day := timeutil.StartOfDay(wh.Date, now.Location())
+		periodStart := goalPeriodStart(goal.Period, day)
+		periodTotals[periodStart] += wh.AchievedHours
+		if first.IsZero() || periodStart.Before(first) {
+			first = periodStart
+		}
+		if !day.Before(start) && !day.After(today) {
+			achievedInPeriod += wh.AchievedHours
+			if day.Equal(today) {
+				achievedToday += wh.AchievedHours
+			}
+		}
+	}
+
+	currentStreak, longestStreak := 0, 0
+	if !first.IsZero() {
+		currentStreak, longestStreak = calculateStreaks(goal.Period, first, start, func(p time.Time) bool {
+			return goal.TargetHours > 0 && periodTotals[p] >= goal.TargetHours
+		})
+	}
+
+	// Spread whatever is left of the target evenly over the remaining days,
+	// today included, and report today's share that is still outstanding.
+	daysLeft := daysBetween(today, end)
+	hoursNeededToday := 0.0
+	if daysLeft > 0 {
+		remaining := goal.TargetHours - (achievedInPeriod - achievedToday)
+		hoursNeededToday = math.Max(remaining/float64(daysLeft)-achievedToday, 0)
+	}
+
+	return map[string]interface{}{
+		"periodStart":      start,
+		"periodEnd":        end.Add(-time.Second),
+		"achievedHours":    achievedInPeriod,
+		"remainingHours":   math.Max(goal.TargetHours-achievedInPeriod, 0),
+		"attainment":       calculateProgress(achievedInPeriod, goal.TargetHours),
+		"hoursNeededToday": hoursNeededToday,
+		"daysLeft":         daysLeft,
+		"currentStreak":    currentStreak,
+		"longestStreak":    longestStreak,
+		"streakUnit":       goal.Period,
+	}
+}
+
+// calculateDailyStreaks counts days on which the hours logged across all
+// categories reached the targets recorded on the entries themselves.
+func calculateDailyStreaks(workingHours []models.WorkingHours, now time.Time) map[string]interface{} {
+	achieved := make(map[time.Time]float64)
+	target := make(map[time.Time]float64)
+	var first time.Time
+	for _, wh := range workingHours {
+		day := timeutil.StartOfDay(wh.Date, now.Location())
+		achieved[day] += wh.AchievedHours
+		target[day] += wh.TargetHours
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+	}
+
+	current, longest := 0, 0
+	if !first.IsZero() {
+		current, longest = calculateStreaks(models.GoalPeriodDaily, first, timeutil.StartOfDay(now, now.Location()), func(day time.Time) bool {
+			return target[day] > 0 && achieved[day] >= target[day]
+		})
+	}
+
+	return map[string]interface{}{
+		"currentStreak": current,
+		"longestStreak": longest,
+	}
+}
+
+func GetWorkingHoursGoals(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	collection := database.GetCollection("workinghoursgoals")
+	opts := options.Find().SetSort(bson.D{{Key: "period", Value: 1}, {Key: "category", Value: 1}})
+	cursor, err := collection.Find(r.Context(), bson.M{"user": userObjID}, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(r.Context())
+
+	var goals []models.WorkingHoursGoal
+	if err = cursor.All(r.Context(), &goals); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	workingHours, err := fetchAllWorkingHours(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().In(userLocation(r))
+	data := []map[string]interface{}{}
+	for _, goal := range goals {
+		data = append(data, map[string]interface{}{
+			"goal":     goal,
+			"progress": calculateGoalProgress(goal, workingHours, now),
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"count":   len(goals),
+		"data":    data,
+		"streaks": calculateDailyStreaks(workingHours, now),
+	})
+}
+
+func CreateWorkingHoursGoal(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Category    string  `json:"category"`
+		Period      string  `json:"period"`
+		TargetHours float64 `json:"targetHours"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	period := models.GoalPeriod(input.Period)
+	if !period.IsValid() {
+		http.Error(w, "Period must be daily, weekly or monthly", http.StatusBadRequest)
+		return
+	}
+	if input.TargetHours <= 0 {
+		http.Error(w, "Target hours must be greater than 0", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("workinghoursgoals")
+
+	category := strings.TrimSpace(input.Category)
+	count, _ := collection.CountDocuments(r.Context(), bson.M{
+		"user":     userObjID,
+		"category": category,
+		"period":   period,
+	})
+	if count > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "A goal for this category and period already exists",
+		})
+		return
+	}
+
+	goal := models.WorkingHoursGoal{
+		ID:          primitive.NewObjectID(),
+		User:        userObjID,
+		Category:    category,
+		Period:      period,
+		TargetHours: input.TargetHours,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	_, err := collection.InsertOne(r.Context(), goal)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    goal,
+	})
+}
+
+// workingHoursGoalPatchSchema only allows the target to change; category and
+// period identify the goal, so changing them means creating a new one.
+var workingHoursGoalPatchSchema = patch.Schema{
+	"targetHours": {Kind: patch.Number, Check: func(value interface{}) string {
+		if value.(float64) <= 0 {
+			return "must be greater than 0"
+		}
+		return ""
+	}},
+}
+
+func UpdateWorkingHoursGoal(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	id := parts[len(parts)-1]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := patch.Decode(r.Body, workingHoursGoalPatchSchema)
+	if err != nil {
+		writePatchError(w, err)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("workinghoursgoals")
+
+	res, err := collection.UpdateOne(r.Context(),
+		bson.M{"_id": objID, "user": userObjID},
+		changes.Update(bson.M{"updatedAt": time.Now()}),
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if res.MatchedCount == 0 {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	var goal models.WorkingHoursGoal
+	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&goal)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    goal,
+	})
+}
+
+func DeleteWorkingHoursGoal(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(r.URL.Path, "/")
+	id := parts[len(parts)-1]
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("workinghoursgoals")
+
+	res, err := collection.DeleteOne(r.Context(), bson.M{"_id": objID, "user": userObjID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if res.DeletedCount == 0 {
+		http.Error(w, "Goal not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Goal deleted successfully",
+	})
+}
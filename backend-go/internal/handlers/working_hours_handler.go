@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"service-exchange-backend-go/internal/auth"
 	"service-exchange-backend-go/internal/database"
 	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/patch"
+	"service-exchange-backend-go/internal/timeutil"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -22,139 +29,405 @@ func calculateProgress(achieved, target float64) float64 {
 	return (achieved / target) * 100
 }
 
-func GetWorkingHours(w http.ResponseWriter, r *http.Request) {
-	startDateStr := r.URL.Query().Get("startDate")
-	endDateStr := r.URL.Query().Get("endDate")
-	category := r.URL.Query().Get("category")
+const (
+	defaultWorkingHoursLimit = 30
+	maxWorkingHoursLimit     = 100
+)
 
-	userID := r.Context().Value(auth.UserContextKey).(string)
-	userObjID, _ := primitive.ObjectIDFromHex(userID)
+// Fields GetWorkingHours can sort on, mapped to whether their values are dates.
+var workingHoursSortFields = map[string]bool{
+	"date":          true,
+	"createdAt":     true,
+	"targetHours":   false,
+	"achievedHours": false,
+}
 
-	query := bson.M{"user": userObjID}
+// workingHoursCursor marks the last row of a page. It is handed to clients
+// as an opaque base64 token and only valid for the sort it was issued for.
+type workingHoursCursor struct {
+	Sort   string     `json:"s"`
+	Order  string     `json:"o"`
+	Time   *time.Time `json:"t,omitempty"`
+	Number *float64   `json:"n,omitempty"`
+	ID     string     `json:"id"`
+}
 
-	if startDateStr != "" && endDateStr != "" {
-		startDate, _ := time.Parse(time.RFC3339, startDateStr)
-		endDate, _ := time.Parse(time.RFC3339, endDateStr)
-		if startDate.IsZero() {
-			startDate, _ = time.Parse("2006-01-02", startDateStr)
-			endDate, _ = time.Parse("2006-01-02", endDateStr)
-		}
+func encodeWorkingHoursCursor(wh models.WorkingHours, sortField, order string) string {
+	c := workingHoursCursor{Sort: sortField, Order: order, ID: wh.ID.Hex()}
+	switch sortField {
+	case "date":
+		c.Time = &wh.Date
+	case "createdAt":
+		c.Time = &wh.CreatedAt
+	case "targetHours":
+		c.Number = &wh.TargetHours
+	case "achievedHours":
+		c.Number = &wh.AchievedHours
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
 
-		if !startDate.IsZero() {
-			query["date"] = bson.M{
-				"$gte": startDate,
-				"$lte": endDate,
-			}
-		}
+// decodeWorkingHoursCursor returns a filter selecting rows strictly after the cursor,
+// using _id as a tie-breaker for rows sharing the same sort value.
+func decodeWorkingHoursCursor(token, sortField, order string) (bson.M, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cursor")
+	}
+	var c workingHoursCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("Invalid cursor")
+	}
+	if c.Sort != sortField || c.Order != order {
+		return nil, fmt.Errorf("Cursor does not match the requested sort")
+	}
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cursor")
+	}
+
+	var value interface{}
+	if workingHoursSortFields[sortField] && c.Time != nil {
+		value = *c.Time
+	} else if !workingHoursSortFields[sortField] && c.Number != nil {
+		value = *c.Number
+	} else {
+		return nil, fmt.Errorf("Invalid cursor")
+	}
+
+	op := "$gt"
+	if order == "desc" {
+		op = "$lt"
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{sortField: bson.M{op: value}},
+		bson.M{sortField: value, "_id": bson.M{op: id}},
+	}}, nil
+}
+
+// buildWorkingHoursQuery applies the startDate, endDate and category filters
+// shared by the working hours listing and its stats.
+func buildWorkingHoursQuery(r *http.Request, userObjID primitive.ObjectID, loc *time.Location) bson.M {
+	category := r.URL.Query().Get("category")
+
+	query := bson.M{"user": userObjID}
+
+	if dateQuery := dateRangeQuery(r.URL.Query().Get("startDate"), r.URL.Query().Get("endDate"), loc); dateQuery != nil {
+		query["date"] = dateQuery
 	}
 
 	if category != "" {
 		query["category"] = category
 	}
 
-	collection := database.GetCollection("workinghours")
-	opts := options.Find().SetSort(bson.M{"date": -1}).SetLimit(30)
+	return query
+}
+
+// aggregateWorkingHoursStats summarises every entry matching query in a
+// single aggregation, independent of how the listing is paginated.
+func aggregateWorkingHoursStats(ctx context.Context, query bson.M) (map[string]interface{}, error) {
+	progress := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{"$targetHours", 0}},
+		bson.M{"$multiply": bson.A{bson.M{"$divide": bson.A{"$achievedHours", "$targetHours"}}, 100}},
+		0,
+	}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: query}},
+		{{Key: "$facet", Value: bson.M{
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":                nil,
+					"totalDays":          bson.M{"$sum": 1},
+					"totalTargetHours":   bson.M{"$sum": "$targetHours"},
+					"totalAchievedHours": bson.M{"$sum": "$achievedHours"},
+					"averageCompletion":  bson.M{"$avg": progress},
+				}},
+			},
+			"categories": bson.A{
+				bson.M{"$group": bson.M{"_id": "$category", "hours": bson.M{"$sum": "$achievedHours"}}},
+			},
+			"moods": bson.A{
+				bson.M{"$group": bson.M{"_id": "$mood", "count": bson.M{"$sum": 1}}},
+			},
+		}}},
+	}
 
-	cursor, err := collection.Find(r.Context(), query, opts)
+	cursor, err := database.GetCollection("workinghours").Aggregate(ctx, pipeline)
 	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Totals []struct {
+			TotalDays          int     `bson:"totalDays"`
+			TotalTargetHours   float64 `bson:"totalTargetHours"`
+			TotalAchievedHours float64 `bson:"totalAchievedHours"`
+			AverageCompletion  float64 `bson:"averageCompletion"`
+		} `bson:"totals"`
+		Categories []struct {
+			Category string  `bson:"_id"`
+			Hours    float64 `bson:"hours"`
+		} `bson:"categories"`
+		Moods []struct {
+			Mood  string `bson:"_id"`
+			Count int    `bson:"count"`
+		} `bson:"moods"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	stats := map[string]interface{}{
+		"totalDays":          0,
+		"totalTargetHours":   0.0,
+		"totalAchievedHours": 0.0,
+		"averageCompletion":  0.0,
+	}
+	categoryBreakdown := make(map[string]float64)
+	moodDistribution := make(map[string]int)
+
+	if len(results) > 0 {
+		if len(results[0].Totals) > 0 {
+			totals := results[0].Totals[0]
+			stats["totalDays"] = totals.TotalDays
+			stats["totalTargetHours"] = totals.TotalTargetHours
+			stats["totalAchievedHours"] = totals.TotalAchievedHours
+			stats["averageCompletion"] = totals.AverageCompletion
+		}
+		for _, c := range results[0].Categories {
+			categoryBreakdown[c.Category] = c.Hours
+		}
+		for _, m := range results[0].Moods {
+			moodDistribution[m.Mood] = m.Count
+		}
+	}
+	stats["categoryBreakdown"] = categoryBreakdown
+	stats["moodDistribution"] = moodDistribution
+
+	return stats, nil
+}
+
+func GetWorkingHours(w http.ResponseWriter, r *http.Request) {
+	limit := defaultWorkingHoursLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 || parsed > maxWorkingHoursLimit {
+			http.Error(w, fmt.Sprintf("Limit must be between 1 and %d", maxWorkingHoursLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	sortField := r.URL.Query().Get("sort")
+	if sortField == "" {
+		sortField = "date"
+	}
+	if _, ok := workingHoursSortFields[sortField]; !ok {
+		http.Error(w, "Sort must be one of date, createdAt, targetHours or achievedHours", http.StatusBadRequest)
+		return
+	}
+
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = "desc"
+	}
+	if order != "asc" && order != "desc" {
+		http.Error(w, "Order must be asc or desc", http.StatusBadRequest)
+		return
+	}
+	direction := 1
+	if order == "desc" {
+		direction = -1
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	// Reading fills in the template's target-only days first.
+	loc := userLocation(r)
+	if err := prefillTemplateDays(r.Context(), userObjID, loc); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	defer cursor.Close(r.Context())
+	query := buildWorkingHoursQuery(r, userObjID, loc)
 
-	var workingHours []models.WorkingHours
-	if err = cursor.All(r.Context(), &workingHours); err != nil {
+	stats, err := aggregateWorkingHoursStats(r.Context(), query)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	totalDays := len(workingHours)
-	var totalTargetHours, totalAchievedHours, sumProgress float64
-	categoryBreakdown := make(map[string]float64)
-	moodDistribution := make(map[string]int)
+	pageQuery := query
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		after, err := decodeWorkingHoursCursor(token, sortField, order)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pageQuery = bson.M{"$and": bson.A{query, after}}
+	}
 
-	for _, wh := range workingHours {
-		totalTargetHours += wh.TargetHours
-		totalAchievedHours += wh.AchievedHours
-		sumProgress += calculateProgress(wh.AchievedHours, wh.TargetHours)
-		categoryBreakdown[wh.Category] += wh.AchievedHours
-		moodDistribution[wh.Mood]++
+	collection := database.GetCollection("workinghours")
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(limit + 1))
+
+	cursor, err := collection.Find(r.Context(), pageQuery, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	defer cursor.Close(r.Context())
 
-	averageCompletion := 0.0
-	if totalDays > 0 {
-		averageCompletion = sumProgress / float64(totalDays)
+	workingHours := []models.WorkingHours{}
+	if err = cursor.All(r.Context(), &workingHours); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	stats := map[string]interface{}{
-		"totalDays":          totalDays,
-		"totalTargetHours":   totalTargetHours,
-		"totalAchievedHours": totalAchievedHours,
-		"averageCompletion":  averageCompletion,
-		"categoryBreakdown":  categoryBreakdown,
-		"moodDistribution":   moodDistribution,
+	var nextCursor interface{}
+	hasMore := len(workingHours) > limit
+	if hasMore {
+		workingHours = workingHours[:limit]
+		nextCursor = encodeWorkingHoursCursor(workingHours[limit-1], sortField, order)
 	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success":      true,
 		"workingHours": workingHours,
 		"stats":        stats,
+		"nextCursor":   nextCursor,
+		"hasMore":      hasMore,
 	})
 }
 
+// validateMood checks a mood against the scale and the optional energy and
+// focus ratings against their range, returning a message for the first
+// problem found or an empty string.
+func validateMood(mood models.Mood, energy, focus *int) string {
+	if !mood.IsValid() {
+		names := []string{}
+		for _, m := range models.MoodScale {
+			names = append(names, string(m))
+		}
+		return "Mood must be one of " + strings.Join(names, ", ")
+	}
+	for name, rating := range map[string]*int{"Energy": energy, "Focus": focus} {
+		if rating != nil && (*rating < models.MinRating || *rating > models.MaxRating) {
+			return fmt.Sprintf("%s must be between %d and %d", name, models.MinRating, models.MaxRating)
+		}
+	}
+	return ""
+}
+
+// workingHoursDayQuery matches a user's entry for the day starting at
+// startOfDay. A user has at most one entry per day, whatever its category.
+func workingHoursDayQuery(userObjID primitive.ObjectID, startOfDay time.Time) bson.M {
+	return bson.M{
+		"user": userObjID,
+		"date": bson.M{
+			"$gte": startOfDay,
+			"$lt":  startOfDay.AddDate(0, 0, 1),
+		},
+	}
+}
+
 func AddWorkingHours(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		Date          string  `json:"date"`
-		TargetHours   float64 `json:"targetHours"`
-		AchievedHours float64 `json:"achievedHours"`
-		Category      string  `json:"category"`
-		Notes         string  `json:"notes"`
-		Mood          string  `json:"mood"`
+		Date          string      `json:"date"`
+		TargetHours   *float64    `json:"targetHours"`
+		AchievedHours float64     `json:"achievedHours"`
+		Category      string      `json:"category"`
+		Notes         string      `json:"notes"`
+		Mood          models.Mood `json:"mood"`
+		Energy        *int        `json:"energy"`
+		Focus         *int        `json:"focus"`
+
+		SkillID *primitive.ObjectID `json:"skillId"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	// An entry linked to a skill defaults to the skill's category.
+	if input.SkillID != nil {
+		skills, ok := loadLinkedSkills(w, r, userObjID, []primitive.ObjectID{*input.SkillID})
+		if !ok {
+			return
+		}
+		if input.Category == "" {
+			input.Category = skills[*input.SkillID].Category
+		}
+	}
 	if input.Category == "" {
 		http.Error(w, "Category is required", http.StatusBadRequest)
 		return
 	}
+	if input.Mood == "" {
+		input.Mood = models.MoodNormal
+	}
+	if msg := validateMood(input.Mood, input.Energy, input.Focus); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
 
-	userID := r.Context().Value(auth.UserContextKey).(string)
-	userObjID, _ := primitive.ObjectIDFromHex(userID)
-
-	date, _ := time.Parse(time.RFC3339, input.Date)
-	if date.IsZero() {
-		date, _ = time.Parse("2006-01-02", input.Date)
+	loc := userLocation(r)
+	date, err := timeutil.ParseDate(input.Date, loc)
+	if err != nil {
+		http.Error(w, "Invalid date", http.StatusBadRequest)
+		return
 	}
-	y, m, d := date.Date()
-	startOfDay := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
+	startOfDay := timeutil.StartOfDay(date, loc)
 
 	collection := database.GetCollection("workinghours")
 
 	var existingEntry models.WorkingHours
-	err := collection.FindOne(r.Context(), bson.M{
-		"user": userObjID,
-		"date": bson.M{
-			"$gte": startOfDay,
-			"$lt":  startOfDay.Add(24 * time.Hour),
-		},
-	}).Decode(&existingEntry)
+	err = collection.FindOne(r.Context(), workingHoursDayQuery(userObjID, startOfDay)).Decode(&existingEntry)
+
+	// An omitted target keeps the one already on the day, otherwise it comes
+	// from the user's weekday template.
+	if input.TargetHours == nil {
+		targetHours := existingEntry.TargetHours
+		if err != nil {
+			template, tmplErr := findWorkingHoursTemplate(r.Context(), userObjID)
+			if tmplErr != nil {
+				http.Error(w, tmplErr.Error(), http.StatusInternalServerError)
+				return
+			}
+			targetHours = templateTarget(template, startOfDay, input.Category)
+		}
+		input.TargetHours = &targetHours
+	}
 
+	// The entry holds the whole day, focus sessions included, so logging
+	// the day again replaces its figures.
 	if err == nil {
-		update := bson.M{
-			"$set": bson.M{
-				"targetHours":   input.TargetHours,
-				"achievedHours": input.AchievedHours,
-				"category":      input.Category,
-				"notes":         input.Notes,
-				"mood":          input.Mood,
-				"updatedAt":     time.Now(),
-			},
+		set := bson.M{
+			"targetHours":   *input.TargetHours,
+			"achievedHours": input.AchievedHours,
+			"category":      input.Category,
+			"notes":         input.Notes,
+			"mood":          input.Mood,
+			"energy":        input.Energy,
+			"focus":         input.Focus,
+			"updatedAt":     time.Now(),
+		}
+		// An omitted skill keeps the link the entry already has.
+		if input.SkillID != nil {
+			set["skillId"] = input.SkillID
 		}
-		collection.UpdateOne(r.Context(), bson.M{"_id": existingEntry.ID}, update)
+		collection.UpdateOne(r.Context(), bson.M{"_id": existingEntry.ID}, bson.M{"$set": set})
 		collection.FindOne(r.Context(), bson.M{"_id": existingEntry.ID}).Decode(&existingEntry)
+		if existingEntry.SkillID != nil && existingEntry.AchievedHours > 0 {
+			startPracticedSkills(r.Context(), userObjID, []primitive.ObjectID{*existingEntry.SkillID})
+		}
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": true,
 			"data":    existingEntry,
@@ -163,16 +436,22 @@ func AddWorkingHours(w http.ResponseWriter, r *http.Request) {
 		newEntry := models.WorkingHours{
 			ID:            primitive.NewObjectID(),
 			User:          userObjID,
-			Date:          date,
-			TargetHours:   input.TargetHours,
+			Date:          startOfDay,
+			TargetHours:   *input.TargetHours,
 			AchievedHours: input.AchievedHours,
 			Category:      input.Category,
 			Notes:         input.Notes,
 			Mood:          input.Mood,
+			Energy:        input.Energy,
+			Focus:         input.Focus,
+			SkillID:       input.SkillID,
 			CreatedAt:     time.Now(),
 			UpdatedAt:     time.Now(),
 		}
 		collection.InsertOne(r.Context(), newEntry)
+		if newEntry.SkillID != nil && newEntry.AchievedHours > 0 {
+			startPracticedSkills(r.Context(), userObjID, []primitive.ObjectID{*newEntry.SkillID})
+		}
 		w.WriteHeader(http.StatusCreated)
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"success": true,
@@ -181,6 +460,28 @@ func AddWorkingHours(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// workingHoursPatchSchema lists the fields UpdateWorkingHours accepts.
+var workingHoursPatchSchema = patch.Schema{
+	"date":          {Kind: patch.String, Check: checkDate},
+	"targetHours":   {Kind: patch.Number, Check: patch.AtLeast(0)},
+	"achievedHours": {Kind: patch.Number, Check: patch.AtLeast(0)},
+	"category":      {Kind: patch.String, Check: patch.NotBlank},
+	"notes":         {Kind: patch.String, Nullable: true},
+	"mood": {Kind: patch.String, Check: func(v interface{}) string {
+		return validateMood(models.Mood(v.(string)), nil, nil)
+	}},
+	"energy":  {Kind: patch.Integer, Nullable: true, Check: patch.Between(models.MinRating, models.MaxRating)},
+	"focus":   {Kind: patch.Integer, Nullable: true, Check: patch.Between(models.MinRating, models.MaxRating)},
+	"skillId": {Kind: patch.String, Nullable: true, Check: checkObjectID},
+}
+
+func checkDate(v interface{}) string {
+	if _, err := timeutil.ParseDate(v.(string), time.UTC); err != nil {
+		return "must be a YYYY-MM-DD or RFC 3339 date"
+	}
+	return ""
+}
+
 func UpdateWorkingHours(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(r.URL.Path, "/")
 	id := parts[len(parts)-1]
@@ -190,20 +491,35 @@ func UpdateWorkingHours(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var input map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	changes, err := patch.Decode(r.Body, workingHoursPatchSchema)
+	if err != nil {
+		writePatchError(w, err)
 		return
 	}
-	delete(input, "user")
+	if changes.Has("date") {
+		loc := userLocation(r)
+		date, _ := timeutil.ParseDate(changes.Values["date"].(string), loc)
+		changes.Values["date"] = timeutil.StartOfDay(date, loc)
+	}
+	if changes.Has("category") {
+		changes.Values["category"] = strings.TrimSpace(changes.Values["category"].(string))
+	}
 
 	userID := r.Context().Value(auth.UserContextKey).(string)
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	collection := database.GetCollection("workinghours")
 
+	if changes.Has("skillId") && changes.Values["skillId"] != nil {
+		skillID, _ := primitive.ObjectIDFromHex(changes.Values["skillId"].(string))
+		if _, ok := loadLinkedSkills(w, r, userObjID, []primitive.ObjectID{skillID}); !ok {
+			return
+		}
+		changes.Values["skillId"] = skillID
+	}
+
 	res, err := collection.UpdateOne(r.Context(),
 		bson.M{"_id": objID, "user": userObjID},
-		bson.M{"$set": input},
+		changes.Update(bson.M{"updatedAt": time.Now()}),
 	)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -216,6 +532,9 @@ func UpdateWorkingHours(w http.ResponseWriter, r *http.Request) {
 
 	var updated models.WorkingHours
 	collection.FindOne(r.Context(), bson.M{"_id": objID}).Decode(&updated)
+	if updated.SkillID != nil && updated.AchievedHours > 0 {
+		startPracticedSkills(r.Context(), userObjID, []primitive.ObjectID{*updated.SkillID})
+	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -260,16 +579,14 @@ func GetWorkingHoursStats(w http.ResponseWriter, r *http.Request) {
 	userObjID, _ := primitive.ObjectIDFromHex(userID)
 	query := bson.M{"user": userObjID}
 
-	if startDateStr != "" && endDateStr != "" {
-		startDate, _ := time.Parse(time.RFC3339, startDateStr)
-		endDate, _ := time.Parse(time.RFC3339, endDateStr)
-		if startDate.IsZero() {
-			startDate, _ = time.Parse("2006-01-02", startDateStr)
-			endDate, _ = time.Parse("2006-01-02", endDateStr)
-		}
-		if !startDate.IsZero() {
-			query["date"] = bson.M{"$gte": startDate, "$lte": endDate}
-		}
+	// Reading fills in the template's target-only days first.
+	loc := userLocation(r)
+	if err := prefillTemplateDays(r.Context(), userObjID, loc); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if dateQuery := dateRangeQuery(startDateStr, endDateStr, loc); dateQuery != nil {
+		query["date"] = dateQuery
 	}
 
 	collection := database.GetCollection("workinghours")
@@ -296,7 +613,7 @@ func GetWorkingHoursStats(w http.ResponseWriter, r *http.Request) {
 		totalAchievedHours += wh.AchievedHours
 		sumProgress += calculateProgress(wh.AchievedHours, wh.TargetHours)
 		categoryBreakdown[wh.Category] += wh.AchievedHours
-		moodDistribution[wh.Mood]++
+		moodDistribution[string(wh.Mood)]++
 	}
 
 	averageCompletion := 0.0
@@ -355,3 +672,193 @@ func GetWorkingHoursCategories(w http.ResponseWriter, r *http.Request) {
 		"categories": allCats,
 	})
 }
+
+type workingHoursBucket struct {
+	Bucket        string  `bson:"bucket" json:"bucket"`
+	TargetHours   float64 `bson:"targetHours" json:"targetHours"`
+	AchievedHours float64 `bson:"achievedHours" json:"achievedHours"`
+	Entries       int     `bson:"entries" json:"entries"`
+	Attainment    float64 `bson:"attainment" json:"attainment"`
+	Met           bool    `bson:"met" json:"met"`
+}
+
+const maxSeriesBuckets = 1000
+
+var seriesIntervals = map[string]models.GoalPeriod{
+	"day":   models.GoalPeriodDaily,
+	"week":  models.GoalPeriodWeekly,
+	"month": models.GoalPeriodMonthly,
+}
+
+// GetWorkingHoursSeries buckets entries by day, week or month inside MongoDB.
+// Buckets are keyed by their calendar date in the requested timezone and
+// re-encoded as UTC midnight so that $densify can step through them without
+// daylight saving shifts when filling empty buckets.
+func GetWorkingHoursSeries(w http.ResponseWriter, r *http.Request) {
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "day"
+	}
+	period, ok := seriesIntervals[interval]
+	if !ok {
+		http.Error(w, "Interval must be day, week or month", http.StatusBadRequest)
+		return
+	}
+
+	// Buckets follow the user's own day boundaries unless a zone is given.
+	loc := userLocation(r)
+	timezone := loc.String()
+	if tz := r.URL.Query().Get("timezone"); tz != "" {
+		if !timeutil.IsValidTimezone(tz) {
+			http.Error(w, "Invalid timezone", http.StatusBadRequest)
+			return
+		}
+		timezone = tz
+		loc = timeutil.Location(tz)
+	}
+
+	endDate := time.Now().In(loc)
+	if endDateStr := r.URL.Query().Get("endDate"); endDateStr != "" {
+		parsed, err := timeutil.ParseDate(endDateStr, loc)
+		if err != nil {
+			http.Error(w, "Invalid date range", http.StatusBadRequest)
+			return
+		}
+		endDate = parsed
+	}
+	lastBucket := goalPeriodStart(period, endDate)
+
+	// Without a start date, show the 30 buckets leading up to the end date.
+	firstBucket := lastBucket
+	for i := 0; i < 29; i++ {
+		firstBucket = goalPeriodStart(period, firstBucket.Add(-time.Hour))
+	}
+	if startDateStr := r.URL.Query().Get("startDate"); startDateStr != "" {
+		startDate, err := timeutil.ParseDate(startDateStr, loc)
+		if err != nil {
+			http.Error(w, "Invalid date range", http.StatusBadRequest)
+			return
+		}
+		firstBucket = goalPeriodStart(period, startDate)
+	}
+	if firstBucket.After(lastBucket) {
+		http.Error(w, "Invalid date range", http.StatusBadRequest)
+		return
+	}
+	rangeEnd := goalPeriodEnd(period, lastBucket)
+
+	bucketCount := 0
+	for b := firstBucket; b.Before(rangeEnd); b = goalPeriodEnd(period, b) {
+		bucketCount++
+	}
+	if bucketCount > maxSeriesBuckets {
+		http.Error(w, fmt.Sprintf("Date range spans more than %d buckets", maxSeriesBuckets), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	// Reading fills in the template's target-only days first.
+	if err := prefillTemplateDays(r.Context(), userObjID, userLocation(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	match := bson.M{
+		"user": userObjID,
+		"date": bson.M{"$gte": firstBucket, "$lt": rangeEnd},
+	}
+	if category := r.URL.Query().Get("category"); category != "" {
+		match["category"] = category
+	}
+
+	inZone := func(op string) bson.M {
+		return bson.M{op: bson.M{"date": "$_id", "timezone": timezone}}
+	}
+	utcDate := func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateTrunc": bson.M{
+				"date":        "$date",
+				"unit":        interval,
+				"timezone":    timezone,
+				"startOfWeek": "monday",
+			}},
+			"targetHours":   bson.M{"$sum": "$targetHours"},
+			"achievedHours": bson.M{"$sum": "$achievedHours"},
+			"entries":       bson.M{"$sum": 1},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"bucket": bson.M{"$dateFromParts": bson.M{
+				"year":  inZone("$year"),
+				"month": inZone("$month"),
+				"day":   inZone("$dayOfMonth"),
+			}},
+		}}},
+		{{Key: "$unset", Value: "_id"}},
+		{{Key: "$densify", Value: bson.M{
+			"field": "bucket",
+			"range": bson.M{
+				"step":   1,
+				"unit":   interval,
+				"bounds": bson.A{utcDate(firstBucket), utcDate(rangeEnd)},
+			},
+		}}},
+		{{Key: "$sort", Value: bson.M{"bucket": 1}}},
+		{{Key: "$set", Value: bson.M{
+			"targetHours":   bson.M{"$ifNull": bson.A{"$targetHours", 0}},
+			"achievedHours": bson.M{"$ifNull": bson.A{"$achievedHours", 0}},
+			"entries":       bson.M{"$ifNull": bson.A{"$entries", 0}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":           0,
+			"bucket":        bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$bucket"}},
+			"targetHours":   1,
+			"achievedHours": 1,
+			"entries":       1,
+			"attainment": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$targetHours", 0}},
+				bson.M{"$multiply": bson.A{bson.M{"$divide": bson.A{"$achievedHours", "$targetHours"}}, 100}},
+				0,
+			}},
+			"met": bson.M{"$and": bson.A{
+				bson.M{"$gt": bson.A{"$targetHours", 0}},
+				bson.M{"$gte": bson.A{"$achievedHours", "$targetHours"}},
+			}},
+		}}},
+	}
+
+	collection := database.GetCollection("workinghours")
+	cursor, err := collection.Aggregate(r.Context(), pipeline)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(r.Context())
+
+	buckets := []workingHoursBucket{}
+	if err = cursor.All(r.Context(), &buckets); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// $densify has nothing to fill between when no entry matched at all.
+	if len(buckets) == 0 {
+		for b := firstBucket; b.Before(rangeEnd); b = goalPeriodEnd(period, b) {
+			buckets = append(buckets, workingHoursBucket{Bucket: b.Format("2006-01-02")})
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"interval": interval,
+		"timezone": timezone,
+		"count":    len(buckets),
+		"data":     buckets,
+	})
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// minMoodInsightEntries is how many entries a mood needs before it is
+// mentioned in an insight, so a single good day doesn't read as a pattern.
+const minMoodInsightEntries = 3
+
+type correlationSums struct {
+	N   float64 `bson:"n"`
+	SX  float64 `bson:"sx"`
+	SY  float64 `bson:"sy"`
+	SXY float64 `bson:"sxy"`
+	SXX float64 `bson:"sxx"`
+	SYY float64 `bson:"syy"`
+}
+
+// pearson returns the correlation coefficient, or nil when there are too few
+// points or one side never varies.
+func (c correlationSums) pearson() interface{} {
+	if c.N < 2 {
+		return nil
+	}
+	denominator := math.Sqrt((c.N*c.SXX - c.SX*c.SX) * (c.N*c.SYY - c.SY*c.SY))
+	if denominator == 0 {
+		return nil
+	}
+	return (c.N*c.SXY - c.SX*c.SY) / denominator
+}
+
+// correlationStage sums what pearson needs for x against the attainment
+// ratio, counting only entries where both are present.
+func correlationStage(x string) bson.M {
+	present := bson.M{"$and": bson.A{
+		bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{x, nil}}, nil}},
+		bson.M{"$ne": bson.A{"$ratio", nil}},
+	}}
+	when := func(value interface{}) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{present, value, 0}}}
+	}
+	return bson.M{
+		"_id": nil,
+		"n":   when(1),
+		"sx":  when(x),
+		"sy":  when("$ratio"),
+		"sxy": when(bson.M{"$multiply": bson.A{x, "$ratio"}}),
+		"sxx": when(bson.M{"$multiply": bson.A{x, x}}),
+		"syy": when(bson.M{"$multiply": bson.A{"$ratio", "$ratio"}}),
+	}
+}
+
+// GetWorkingHoursMoodStats relates mood, energy and focus to how much of the
+// target was achieved, overall and split by category and weekday.
+func GetWorkingHoursMoodStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	loc := userLocation(r)
+	query := buildWorkingHoursQuery(r, userObjID, loc)
+
+	scoreBranches := bson.A{}
+	for _, mood := range models.MoodScale {
+		scoreBranches = append(scoreBranches, bson.M{
+			"case": bson.M{"$eq": bson.A{"$mood", mood}},
+			"then": mood.Score(),
+		})
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: query}},
+		{{Key: "$set", Value: bson.M{
+			"ratio": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$targetHours", 0}},
+				bson.M{"$divide": bson.A{"$achievedHours", "$targetHours"}},
+				nil,
+			}},
+			"moodScore": bson.M{"$switch": bson.M{"branches": scoreBranches, "default": nil}},
+			"weekday":   bson.M{"$isoDayOfWeek": bson.M{"date": "$date", "timezone": loc.String()}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"overall": bson.A{bson.M{"$group": bson.M{
+				"_id":      nil,
+				"entries":  bson.M{"$sum": 1},
+				"avgRatio": bson.M{"$avg": "$ratio"},
+			}}},
+			"byMood": bson.A{bson.M{"$group": bson.M{
+				"_id":           "$mood",
+				"entries":       bson.M{"$sum": 1},
+				"avgRatio":      bson.M{"$avg": "$ratio"},
+				"achievedHours": bson.M{"$sum": "$achievedHours"},
+				"avgEnergy":     bson.M{"$avg": "$energy"},
+				"avgFocus":      bson.M{"$avg": "$focus"},
+			}}},
+			"byCategory": bson.A{bson.M{"$group": bson.M{
+				"_id":      bson.M{"category": "$category", "mood": "$mood"},
+				"entries":  bson.M{"$sum": 1},
+				"avgRatio": bson.M{"$avg": "$ratio"},
+			}}},
+			"byWeekday": bson.A{bson.M{"$group": bson.M{
+				"_id":       "$weekday",
+				"entries":   bson.M{"$sum": 1},
+				"avgRatio":  bson.M{"$avg": "$ratio"},
+				"avgScore":  bson.M{"$avg": "$moodScore"},
+				"avgEnergy": bson.M{"$avg": "$energy"},
+				"avgFocus":  bson.M{"$avg": "$focus"},
+			}}},
+			"byWeekdayMood": bson.A{bson.M{"$group": bson.M{
+				"_id":     bson.M{"weekday": "$weekday", "mood": "$mood"},
+				"entries": bson.M{"$sum": 1},
+			}}},
+			"moodCorrelation":   bson.A{bson.M{"$group": correlationStage("$moodScore")}},
+			"energyCorrelation": bson.A{bson.M{"$group": correlationStage("$energy")}},
+			"focusCorrelation":  bson.A{bson.M{"$group": correlationStage("$focus")}},
+		}}},
+	}
+
+	cursor, err := database.GetCollection("workinghours").Aggregate(r.Context(), pipeline)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(r.Context())
+
+	type group struct {
+		Entries       int      `bson:"entries"`
+		AvgRatio      *float64 `bson:"avgRatio"`
+		AchievedHours float64  `bson:"achievedHours"`
+		AvgScore      *float64 `bson:"avgScore"`
+		AvgEnergy     *float64 `bson:"avgEnergy"`
+		AvgFocus      *float64 `bson:"avgFocus"`
+	}
+	var results []struct {
+		Overall []group `bson:"overall"`
+		ByMood  []struct {
+			Mood  models.Mood `bson:"_id"`
+			Group group       `bson:",inline"`
+		} `bson:"byMood"`
+		ByCategory []struct {
+			Key struct {
+				Category string      `bson:"category"`
+				Mood     models.Mood `bson:"mood"`
+			} `bson:"_id"`
+			Group group `bson:",inline"`
+		} `bson:"byCategory"`
+		ByWeekday []struct {
+			Weekday int   `bson:"_id"`
+			Group   group `bson:",inline"`
+		} `bson:"byWeekday"`
+		ByWeekdayMood []struct {
+			Key struct {
+				Weekday int         `bson:"weekday"`
+				Mood    models.Mood `bson:"mood"`
+			} `bson:"_id"`
+			Entries int `bson:"entries"`
+		} `bson:"byWeekdayMood"`
+		MoodCorrelation   []correlationSums `bson:"moodCorrelation"`
+		EnergyCorrelation []correlationSums `bson:"energyCorrelation"`
+		FocusCorrelation  []correlationSums `bson:"focusCorrelation"`
+	}
+	if err = cursor.All(r.Context(), &results); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Averages are reported as percentages of the target, like elsewhere.
+	percent := func(ratio *float64) interface{} {
+		if ratio == nil {
+			return nil
+		}
+		return *ratio * 100
+	}
+	correlation := func(sums []correlationSums) interface{} {
+		if len(sums) == 0 {
+			return nil
+		}
+		return sums[0].pearson()
+	}
+
+	baselineEntries := 0
+	var baseline *float64
+	byMood := []map[string]interface{}{}
+	byCategory := make(map[string]map[string]interface{})
+	byWeekday := []map[string]interface{}{}
+	insights := []string{}
+	correlations := map[string]interface{}{"moodScore": nil, "energy": nil, "focus": nil}
+
+	if len(results) > 0 {
+		res := results[0]
+		if len(res.Overall) > 0 {
+			baselineEntries = res.Overall[0].Entries
+			baseline = res.Overall[0].AvgRatio
+		}
+
+		moods := make(map[models.Mood]group)
+		for _, m := range res.ByMood {
+			moods[m.Mood] = m.Group
+		}
+		for _, mood := range models.MoodScale {
+			g, ok := moods[mood]
+			if !ok {
+				continue
+			}
+			var lift interface{}
+			if baseline != nil && *baseline > 0 && g.AvgRatio != nil {
+				liftPercent := (*g.AvgRatio - *baseline) / *baseline * 100
+				lift = liftPercent
+				if g.Entries >= minMoodInsightEntries && math.Abs(liftPercent) >= 1 {
+					direction := "more"
+					if liftPercent < 0 {
+						direction = "less"
+					}
+					insights = append(insights, fmt.Sprintf("You hit %.0f%% %s of your target on '%s' days", math.Abs(liftPercent), direction, mood))
+				}
+			}
+			byMood = append(byMood, map[string]interface{}{
+				"mood":               mood,
+				"score":              mood.Score(),
+				"entries":            g.Entries,
+				"averageAttainment":  percent(g.AvgRatio),
+				"liftPercent":        lift,
+				"totalAchievedHours": g.AchievedHours,
+				"averageEnergy":      g.AvgEnergy,
+				"averageFocus":       g.AvgFocus,
+			})
+		}
+
+		for _, c := range res.ByCategory {
+			if _, ok := byCategory[c.Key.Category]; !ok {
+				byCategory[c.Key.Category] = make(map[string]interface{})
+			}
+			byCategory[c.Key.Category][string(c.Key.Mood)] = map[string]interface{}{
+				"entries":           c.Group.Entries,
+				"averageAttainment": percent(c.Group.AvgRatio),
+			}
+		}
+
+		weekdayMoods := make(map[int]map[string]int)
+		for _, wm := range res.ByWeekdayMood {
+			if _, ok := weekdayMoods[wm.Key.Weekday]; !ok {
+				weekdayMoods[wm.Key.Weekday] = make(map[string]int)
+			}
+			weekdayMoods[wm.Key.Weekday][string(wm.Key.Mood)] = wm.Entries
+		}
+		weekdays := make(map[int]group)
+		for _, wd := range res.ByWeekday {
+			weekdays[wd.Weekday] = wd.Group
+		}
+		// $isoDayOfWeek runs 1 (Monday) to 7 (Sunday).
+		for iso := 1; iso <= 7; iso++ {
+			g, ok := weekdays[iso]
+			if !ok {
+				continue
+			}
+			byWeekday = append(byWeekday, map[string]interface{}{
+				"weekday":           time.Weekday(iso % 7).String(),
+				"entries":           g.Entries,
+				"averageAttainment": percent(g.AvgRatio),
+				"averageMoodScore":  g.AvgScore,
+				"averageEnergy":     g.AvgEnergy,
+				"averageFocus":      g.AvgFocus,
+				"moods":             weekdayMoods[iso],
+			})
+		}
+
+		correlations["moodScore"] = correlation(res.MoodCorrelation)
+		correlations["energy"] = correlation(res.EnergyCorrelation)
+		correlations["focus"] = correlation(res.FocusCorrelation)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"baseline": map[string]interface{}{
+				"entries":           baselineEntries,
+				"averageAttainment": percent(baseline),
+			},
+			"byMood":       byMood,
+			"byCategory":   byCategory,
+			"byWeekday":    byWeekday,
+			"correlations": correlations,
+			"insights":     insights,
+		},
+	})
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// projectionLookbackWeeks is how many past weeks the weekday averages are
+// taken over.
+const projectionLookbackWeeks = 8
+
+// weekProjection is the pacing for one category, or for all of them.
+type weekProjection struct {
+	Category            string  `json:"category"`
+	TargetHours         float64 `json:"targetHours"`
+	TargetSource        string  `json:"targetSource"`
+	AchievedHours       float64 `json:"achievedHours"`
+	HoursNeeded         float64 `json:"hoursNeeded"`
+	RequiredDailyPace   float64 `json:"requiredDailyPace"`
+	PredictedHours      float64 `json:"predictedHours"`
+	PredictedAttainment float64 `json:"predictedAttainment"`
+	Status              string  `json:"status"`
+}
+
+// finish fills in the fields derived from target, achieved and predicted
+// hours. A week with no target has nothing to be at risk of missing.
+func (p *weekProjection) finish(daysLeft int) {
+	p.HoursNeeded = math.Max(p.TargetHours-p.AchievedHours, 0)
+	if daysLeft > 0 {
+		p.RequiredDailyPace = p.HoursNeeded / float64(daysLeft)
+	}
+	p.PredictedAttainment = calculateProgress(p.PredictedHours, p.TargetHours)
+	switch {
+	case p.TargetHours <= 0:
+		p.Status = "no-target"
+	case p.AchievedHours >= p.TargetHours:
+		p.Status = "met"
+	case p.PredictedHours >= p.TargetHours:
+		p.Status = "on-track"
+	default:
+		p.Status = "at-risk"
+	}
+}
+
+// GetWorkingHoursProjection projects the current week per category. Targets
+// come from a weekly goal when there is one, then a daily goal times seven,
+// and otherwise from the targets on this week's entries, with the weekday
+// template standing in for days not logged yet. The prediction adds the
+// user's average for each remaining weekday over the last few weeks.
+func GetWorkingHoursProjection(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	loc := userLocation(r)
+	now := time.Now().In(loc)
+	today := timeutil.StartOfDay(now, loc)
+	weekStart := timeutil.StartOfWeek(now, loc)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	historyStart := weekStart.AddDate(0, 0, -7*projectionLookbackWeeks)
+	daysLeft := daysBetween(today, weekEnd)
+
+	workingHours, err := fetchAllWorkingHours(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cursor, err := database.GetCollection("workinghoursgoals").Find(r.Context(), bson.M{"user": userObjID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var goals []models.WorkingHoursGoal
+	if err = cursor.All(r.Context(), &goals); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	template, err := findWorkingHoursTemplate(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type dayKey struct {
+		category string
+		day      time.Time
+	}
+	type weekdayKey struct {
+		category string
+		weekday  time.Weekday
+	}
+	categories := make(map[string]bool)
+	achieved := make(map[dayKey]float64)
+	targets := make(map[dayKey]float64)
+	logged := make(map[dayKey]bool)
+	history := make(map[weekdayKey]float64)
+	var firstEntry time.Time
+
+	for _, wh := range workingHours {
+		day := timeutil.StartOfDay(wh.Date, loc)
+		if firstEntry.IsZero() || day.Before(firstEntry) {
+			firstEntry = day
+		}
+		switch {
+		case !day.Before(weekStart) && day.Before(weekEnd):
+			key := dayKey{wh.Category, day}
+			achieved[key] += wh.AchievedHours
+			targets[key] += wh.TargetHours
+			logged[key] = true
+			categories[wh.Category] = true
+		case !day.Before(historyStart) && day.Before(weekStart):
+			history[weekdayKey{wh.Category, day.Weekday()}] += wh.AchievedHours
+		}
+	}
+
+	// Average over the weeks the user has actually been logging, so a new
+	// user is not dragged down by weeks before they started.
+	weeksObserved := projectionLookbackWeeks
+	if !firstEntry.IsZero() && firstEntry.After(historyStart) {
+		weeksObserved = int(math.Ceil(float64(daysBetween(firstEntry, weekStart)) / 7))
+	}
+
+	goalTargets := make(map[string]models.WorkingHoursGoal)
+	for _, goal := range goals {
+		if goal.Period == models.GoalPeriodMonthly {
+			continue
+		}
+		// A weekly goal wins over a daily one for the same category.
+		if existing, ok := goalTargets[goal.Category]; ok && existing.Period == models.GoalPeriodWeekly {
+			continue
+		}
+		goalTargets[goal.Category] = goal
+		if goal.Category != "" {
+			categories[goal.Category] = true
+		}
+	}
+	if template != nil {
+		for _, target := range template.Targets {
+			if target.Category != "" {
+				categories[target.Category] = true
+			}
+		}
+		if template.PrefillCategory != "" {
+			categories[template.PrefillCategory] = true
+		}
+	}
+
+	// templateTargetFor only counts a weekday's general target towards the
+	// prefill category, so it isn't repeated for every category.
+	templateTargetFor := func(weekday time.Weekday, category string) float64 {
+		if template == nil {
+			return 0
+		}
+		for _, target := range template.Targets {
+			if target.Weekday == weekday && target.Category == category {
+				return target.TargetHours
+			}
+		}
+		if category == template.PrefillCategory {
+			hours, _ := template.TargetFor(weekday, category)
+			return hours
+		}
+		return 0
+	}
+
+	project := func(category string) weekProjection {
+		p := weekProjection{Category: category}
+		entryTarget := 0.0
+		for day := weekStart; day.Before(weekEnd); day = day.AddDate(0, 0, 1) {
+			key := dayKey{category, day}
+			if !day.After(today) {
+				p.AchievedHours += achieved[key]
+			}
+			if logged[key] {
+				entryTarget += targets[key]
+			} else {
+				entryTarget += templateTargetFor(day.Weekday(), category)
+			}
+			if !day.Before(today) && weeksObserved > 0 {
+				average := history[weekdayKey{category, day.Weekday()}] / float64(weeksObserved)
+				p.PredictedHours += math.Max(average-achieved[key], 0)
+			}
+		}
+		p.PredictedHours += p.AchievedHours
+
+		if goal, ok := goalTargets[category]; ok {
+			p.TargetHours = goal.TargetHours
+			p.TargetSource = "weekly-goal"
+			if goal.Period == models.GoalPeriodDaily {
+				p.TargetHours *= 7
+				p.TargetSource = "daily-goal"
+			}
+		} else {
+			p.TargetHours = entryTarget
+			p.TargetSource = "entries"
+		}
+		p.finish(daysLeft)
+		return p
+	}
+
+	names := make([]string, 0, len(categories))
+	for category := range categories {
+		names = append(names, category)
+	}
+	sort.Strings(names)
+
+	overall := weekProjection{Category: "", TargetSource: "categories"}
+	byCategory := []weekProjection{}
+	for _, category := range names {
+		p := project(category)
+		byCategory = append(byCategory, p)
+		overall.TargetHours += p.TargetHours
+		overall.AchievedHours += p.AchievedHours
+		overall.PredictedHours += p.PredictedHours
+	}
+	if goal, ok := goalTargets[""]; ok {
+		overall.TargetHours = goal.TargetHours
+		overall.TargetSource = "weekly-goal"
+		if goal.Period == models.GoalPeriodDaily {
+			overall.TargetHours *= 7
+			overall.TargetSource = "daily-goal"
+		}
+	}
+	overall.finish(daysLeft)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"weekStart":     weekStart,
+			"weekEnd":       weekEnd.Add(-time.Second),
+			"daysLeft":      daysLeft,
+			"weeksObserved": weeksObserved,
+			"overall":       overall,
+			"byCategory":    byCategory,
+		},
+	})
+}
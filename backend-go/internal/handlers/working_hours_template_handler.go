@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxPrefillDays caps how many days a single request pre-creates, so a user
+// returning after a long break catches up over a few requests.
+const maxPrefillDays = 366
+
+// findWorkingHoursTemplate returns the user's template, or nil when they have
+// not set one up.
+func findWorkingHoursTemplate(ctx context.Context, userObjID primitive.ObjectID) (*models.WorkingHoursTemplate, error) {
+	var template models.WorkingHoursTemplate
+	err := database.GetCollection("workinghourstemplates").FindOne(ctx, bson.M{"user": userObjID}).Decode(&template)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// templateTarget resolves the target for an entry that was logged without
+// one, falling back to 0 when there is no template or no matching weekday.
+func templateTarget(template *models.WorkingHoursTemplate, day time.Time, category string) float64 {
+	if template == nil {
+		return 0
+	}
+	hours, _ := template.TargetFor(day.Weekday(), category)
+	return hours
+}
+
+// prefillTemplateDays creates target-only entries for the days since the
+// template was last applied, up to and including today. Days that already
+// have an entry are left untouched. It runs lazily from GetWorkingHours,
+// GetWorkingHoursStats and GetWorkingHoursSeries, so those reads may write:
+// the entries are created before they are listed.
+func prefillTemplateDays(ctx context.Context, userObjID primitive.ObjectID, loc *time.Location) error {
+	template, err := findWorkingHoursTemplate(ctx, userObjID)
+	if err != nil || template == nil || template.PrefillCategory == "" {
+		return err
+	}
+
+	today := timeutil.StartOfDay(time.Now(), loc)
+	from := timeutil.StartOfDay(template.CreatedAt, loc)
+	if !template.FilledThrough.IsZero() {
+		from = timeutil.StartOfDay(template.FilledThrough, loc).AddDate(0, 0, 1)
+	}
+	if from.After(today) {
+		return nil
+	}
+
+	now := time.Now()
+	var writes []mongo.WriteModel
+	through := from
+	for day, n := from, 0; !day.After(today) && n < maxPrefillDays; day, n = day.AddDate(0, 0, 1), n+1 {
+		through = day
+		hours, ok := template.TargetFor(day.Weekday(), template.PrefillCategory)
+		if !ok || hours <= 0 {
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(workingHoursDayQuery(userObjID, day)).
+			SetUpdate(bson.M{"$setOnInsert": models.WorkingHours{
+				ID:          primitive.NewObjectID(),
+				User:        userObjID,
+				Date:        day,
+				TargetHours: hours,
+				Category:    template.PrefillCategory,
+				Mood:        models.MoodNormal,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}}).
+			SetUpsert(true))
+	}
+
+	if len(writes) > 0 {
+		if _, err := database.GetCollection("workinghours").BulkWrite(ctx, writes); err != nil {
+			return err
+		}
+	}
+	_, err = database.GetCollection("workinghourstemplates").UpdateOne(ctx,
+		bson.M{"_id": template.ID},
+		bson.M{"$set": bson.M{"filledThrough": through}},
+	)
+	return err
+}
+
+func GetWorkingHoursTemplate(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	template, err := findWorkingHoursTemplate(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// UpdateWorkingHoursTemplate replaces the user's template. Prefilling starts
+// from the day the template is first saved and never reaches back further.
+func UpdateWorkingHoursTemplate(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Targets         []models.WeekdayTarget `json:"targets"`
+		PrefillCategory string                 `json:"prefillCategory"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	seen := make(map[string]bool)
+	for i := range input.Targets {
+		target := &input.Targets[i]
+		target.Category = strings.TrimSpace(target.Category)
+		if target.Weekday < time.Sunday || target.Weekday > time.Saturday {
+			http.Error(w, "Weekday must be between 0 (Sunday) and 6 (Saturday)", http.StatusBadRequest)
+			return
+		}
+		if target.TargetHours < 0 || target.TargetHours > 24 {
+			http.Error(w, "Target hours must be between 0 and 24", http.StatusBadRequest)
+			return
+		}
+		key := fmt.Sprintf("%d/%s", target.Weekday, target.Category)
+		if seen[key] {
+			http.Error(w, fmt.Sprintf("%s has more than one target for the same category", target.Weekday), http.StatusBadRequest)
+			return
+		}
+		seen[key] = true
+	}
+	if input.Targets == nil {
+		input.Targets = []models.WeekdayTarget{}
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	collection := database.GetCollection("workinghourstemplates")
+
+	now := time.Now()
+	_, err := collection.UpdateOne(r.Context(),
+		bson.M{"user": userObjID},
+		bson.M{
+			"$set": bson.M{
+				"targets":         input.Targets,
+				"prefillCategory": strings.TrimSpace(input.PrefillCategory),
+				"updatedAt":       now,
+			},
+			"$setOnInsert": bson.M{"createdAt": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	template, err := findWorkingHoursTemplate(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    template,
+	})
+}
+
+func DeleteWorkingHoursTemplate(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	res, err := database.GetCollection("workinghourstemplates").DeleteOne(r.Context(), bson.M{"user": userObjID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if res.DeletedCount == 0 {
+		http.Error(w, "Template not found", http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Template deleted successfully",
+	})
+}
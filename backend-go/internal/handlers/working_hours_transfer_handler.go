@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/auth"
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxImportBytes = 5 << 20
+	maxImportRows  = 5000
+)
+
+// workingHoursColumns is the column order of exports and the set of fields
+// an import can map spreadsheet columns onto.
+var workingHoursColumns = []string{"date", "category", "targetHours", "achievedHours", "mood", "energy", "focus", "notes"}
+
+func workingHoursRecord(wh models.WorkingHours, loc *time.Location) []string {
+	rating := func(v *int) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	}
+	return []string{
+		wh.Date.In(loc).Format(timeutil.DateLayout),
+		wh.Category,
+		strconv.FormatFloat(wh.TargetHours, 'f', -1, 64),
+		strconv.FormatFloat(wh.AchievedHours, 'f', -1, 64),
+		string(wh.Mood),
+		rating(wh.Energy),
+		rating(wh.Focus),
+		wh.Notes,
+	}
+}
+
+// ExportWorkingHours streams every entry matching the GetWorkingHours filters
+// as CSV or as a JSON array, oldest first. Both formats can be imported again.
+func ExportWorkingHours(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "Format must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+
+	loc := userLocation(r)
+	query := buildWorkingHoursQuery(r, userObjID, loc)
+
+	collection := database.GetCollection("workinghours")
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := collection.Find(r.Context(), query, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer cursor.Close(r.Context())
+
+	filename := "working-hours-" + time.Now().In(loc).Format(timeutil.DateLayout) + "." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	flusher, _ := w.(http.Flusher)
+
+	// Headers are already sent once rows are streamed, so a failure part way
+	// through can only cut the export short.
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		writer := csv.NewWriter(w)
+		writer.Write(workingHoursColumns)
+		for cursor.Next(r.Context()) {
+			var wh models.WorkingHours
+			if err := cursor.Decode(&wh); err != nil {
+				return
+			}
+			writer.Write(workingHoursRecord(wh, loc))
+			writer.Flush()
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, "[")
+	for first := true; cursor.Next(r.Context()); first = false {
+		var wh models.WorkingHours
+		if err := cursor.Decode(&wh); err != nil {
+			return
+		}
+		row := make(map[string]interface{}, len(workingHoursColumns))
+		for i, value := range workingHoursRecord(wh, loc) {
+			row[workingHoursColumns[i]] = value
+		}
+		row["targetHours"] = wh.TargetHours
+		row["achievedHours"] = wh.AchievedHours
+		row["energy"] = wh.Energy
+		row["focus"] = wh.Focus
+		if !first {
+			io.WriteString(w, ",")
+		}
+		json.NewEncoder(w).Encode(row)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	io.WriteString(w, "]\n")
+}
+
+// importRow is one parsed line of an import. Only the fields whose column was
+// present and non-empty are set, so merging never blanks existing values.
+type importRow struct {
+	Line   int
+	Day    time.Time
+	Fields bson.M
+	Errors []string
+}
+
+// importMapping resolves each field to the source column it is read from.
+// Fields without an explicit mapping are read from a column of the same name.
+func importMapping(mapping map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(workingHoursColumns))
+	for _, field := range workingHoursColumns {
+		resolved[field] = field
+	}
+	for field, column := range mapping {
+		if _, ok := resolved[field]; !ok {
+			return nil, fmt.Errorf("Cannot map column to unknown field '%s'", field)
+		}
+		resolved[field] = column
+	}
+	return resolved, nil
+}
+
+// readImportRecords turns the uploaded content into one map per row, keyed by
+// source column name.
+func readImportRecords(format, content string, rows []map[string]interface{}) ([]map[string]string, error) {
+	records := []map[string]string{}
+	switch format {
+	case "csv":
+		reader := csv.NewReader(strings.NewReader(content))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		lines, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV: %v", err)
+		}
+		if len(lines) == 0 {
+			return records, nil
+		}
+		header := lines[0]
+		for _, line := range lines[1:] {
+			record := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(line) {
+					record[strings.TrimSpace(column)] = line[i]
+				}
+			}
+			records = append(records, record)
+		}
+	case "json":
+		for _, row := range rows {
+			record := make(map[string]string, len(row))
+			for column, value := range row {
+				if value != nil {
+					record[column] = fmt.Sprint(value)
+				}
+			}
+			records = append(records, record)
+		}
+	default:
+		return nil, fmt.Errorf("Format must be csv or json")
+	}
+	return records, nil
+}
+
+// parseImportRow validates a row with the same rules as AddWorkingHours.
+func parseImportRow(record, mapping map[string]string, loc *time.Location) importRow {
+	row := importRow{Fields: bson.M{}}
+	value := func(field string) string {
+		return strings.TrimSpace(record[mapping[field]])
+	}
+
+	if date := value("date"); date == "" {
+		row.Errors = append(row.Errors, "date is required")
+	} else if parsed, err := timeutil.ParseDate(date, loc); err != nil {
+		row.Errors = append(row.Errors, "date must be YYYY-MM-DD or RFC 3339")
+	} else {
+		row.Day = timeutil.StartOfDay(parsed, loc)
+	}
+
+	if category := value("category"); category == "" {
+		row.Errors = append(row.Errors, "category is required")
+	} else {
+		row.Fields["category"] = category
+	}
+
+	for _, field := range []string{"targetHours", "achievedHours"} {
+		if raw := value(field); raw != "" {
+			hours, err := strconv.ParseFloat(raw, 64)
+			if err != nil || hours < 0 {
+				row.Errors = append(row.Errors, field+" must be a number of at least 0")
+				continue
+			}
+			row.Fields[field] = hours
+		}
+	}
+
+	mood := models.Mood(value("mood"))
+	rating := func(field string) *int {
+		raw := value(field)
+		if raw == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			row.Errors = append(row.Errors, field+" must be a whole number")
+			return nil
+		}
+		row.Fields[field] = n
+		return &n
+	}
+	energy, focus := rating("energy"), rating("focus")
+	if mood != "" {
+		row.Fields["mood"] = mood
+	} else {
+		mood = models.MoodNormal
+	}
+	if msg := validateMood(mood, energy, focus); msg != "" {
+		row.Errors = append(row.Errors, msg)
+	}
+
+	if notes := value("notes"); notes != "" {
+		row.Fields["notes"] = notes
+	}
+	return row
+}
+
+// ImportWorkingHours loads spreadsheet rows into workinghours. Rows are
+// matched to existing entries by day, like AddWorkingHours; onExisting picks
+// whether a matched day is merged with the row or left alone. Invalid rows
+// are reported and skipped, and dryRun reports what would happen without
+// writing anything.
+func ImportWorkingHours(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Format     string                   `json:"format"`
+		Content    string                   `json:"content"`
+		Rows       []map[string]interface{} `json:"rows"`
+		Mapping    map[string]string        `json:"mapping"`
+		OnExisting string                   `json:"onExisting"`
+		DryRun     bool                     `json:"dryRun"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if input.Format == "" {
+		input.Format = "csv"
+	}
+	if input.OnExisting == "" {
+		input.OnExisting = "merge"
+	}
+	if input.OnExisting != "merge" && input.OnExisting != "skip" {
+		http.Error(w, "onExisting must be merge or skip", http.StatusBadRequest)
+		return
+	}
+
+	mapping, err := importMapping(input.Mapping)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	records, err := readImportRecords(input.Format, input.Content, input.Rows)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(records) > maxImportRows {
+		http.Error(w, fmt.Sprintf("Imports are limited to %d rows", maxImportRows), http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(auth.UserContextKey).(string)
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	loc := userLocation(r)
+
+	rows := make([]importRow, len(records))
+	var first, last time.Time
+	for i, record := range records {
+		rows[i] = parseImportRow(record, mapping, loc)
+		// CSV line numbers count the header; JSON rows are numbered from 1.
+		rows[i].Line = i + 1
+		if input.Format == "csv" {
+			rows[i].Line = i + 2
+		}
+		if len(rows[i].Errors) == 0 {
+			if first.IsZero() || rows[i].Day.Before(first) {
+				first = rows[i].Day
+			}
+			if last.IsZero() || rows[i].Day.After(last) {
+				last = rows[i].Day
+			}
+		}
+	}
+
+	collection := database.GetCollection("workinghours")
+
+	// Load every entry in the imported range once, keyed by the same day
+	// window AddWorkingHours matches on.
+	existing := make(map[time.Time]primitive.ObjectID)
+	if !first.IsZero() {
+		cursor, err := collection.Find(r.Context(), bson.M{
+			"user": userObjID,
+			"date": bson.M{"$gte": first, "$lt": last.AddDate(0, 0, 1)},
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		var entries []models.WorkingHours
+		if err = cursor.All(r.Context(), &entries); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, entry := range entries {
+			existing[timeutil.StartOfDay(entry.Date, loc)] = entry.ID
+		}
+	}
+
+	template, err := findWorkingHoursTemplate(r.Context(), userObjID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	summary := map[string]int{"rows": len(rows), "created": 0, "merged": 0, "skipped": 0, "invalid": 0}
+	report := []map[string]interface{}{}
+	var writes []mongo.WriteModel
+
+	for _, row := range rows {
+		result := map[string]interface{}{"row": row.Line}
+		if !row.Day.IsZero() {
+			result["date"] = row.Day.Format(timeutil.DateLayout)
+		}
+
+		id, found := existing[row.Day]
+		switch {
+		case len(row.Errors) > 0:
+			result["action"] = "invalid"
+			result["errors"] = row.Errors
+			summary["invalid"]++
+		case found && input.OnExisting == "skip":
+			result["action"] = "skipped"
+			summary["skipped"]++
+		case found:
+			row.Fields["updatedAt"] = now
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": id}).
+				SetUpdate(bson.M{"$set": row.Fields}))
+			result["action"] = "merged"
+			summary["merged"]++
+		default:
+			doc := bson.M{
+				"_id":           primitive.NewObjectID(),
+				"user":          userObjID,
+				"date":          row.Day,
+				"targetHours":   templateTarget(template, row.Day, row.Fields["category"].(string)),
+				"achievedHours": 0.0,
+				"mood":          models.MoodNormal,
+				"createdAt":     now,
+				"updatedAt":     now,
+			}
+			for field, value := range row.Fields {
+				doc[field] = value
+			}
+			// Later rows for the same day merge into this one.
+			existing[row.Day] = doc["_id"].(primitive.ObjectID)
+			writes = append(writes, mongo.NewInsertOneModel().SetDocument(doc))
+			result["action"] = "created"
+			summary["created"]++
+		}
+		report = append(report, result)
+	}
+
+	if !input.DryRun && len(writes) > 0 {
+		opts := options.BulkWrite().SetOrdered(true)
+		if _, err := collection.BulkWrite(r.Context(), writes, opts); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"dryRun":  input.DryRun,
+		"summary": summary,
+		"rows":    report,
+	})
+}
@@ -0,0 +1,272 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotCalendar is returned for input without a VCALENDAR.
+var ErrNotCalendar = errors.New("not an iCalendar file")
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfold reads content lines, joining folded continuations back on.
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits `NAME;PARAM=value:VALUE`, allowing quoted parameter
+// values that contain ':' or ';'.
+func parseProperty(line string) (property, bool) {
+	colon := -1
+	quoted := false
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+
+	p := property{params: map[string]string{}, value: line[colon+1:]}
+	head := line[:colon]
+	var parts []string
+	start := 0
+	quoted = false
+	for i, c := range head {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ';' && !quoted {
+			parts = append(parts, head[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, head[start:])
+
+	p.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if name, value, ok := strings.Cut(param, "="); ok {
+			p.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return p, true
+}
+
+// UnescapeText reverses EscapeText.
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// splitList splits a comma-separated TEXT list, leaving escaped commas in
+// place.
+func splitList(s string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, s[start:])
+}
+
+// parseTime reads a DATE or DATE-TIME value. Times in UTC or another TZID
+// are converted to loc; floating times are taken to be in loc already. An
+// unknown TZID is treated as floating.
+func parseTime(p property, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.value)
+	if p.params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t.In(loc), false, err
+	}
+	zone := loc
+	if tzid := strings.TrimPrefix(p.params["TZID"], "/"); tzid != "" {
+		if z, err := time.LoadLocation(tzid); err == nil {
+			zone = z
+		}
+	}
+	t, err := time.ParseInLocation(localLayout, value, zone)
+	return t.In(loc), false, err
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads a DURATION value such as "PT1H30M" or "P1D".
+func parseDuration(value string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid DURATION %q", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] != "" {
+			n, _ := strconv.Atoi(m[i+2])
+			d += time.Duration(n) * unit
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, nil
+}
+
+// Parse reads the VEVENTs of a calendar, with times in loc. Events without
+// a usable DTSTART are skipped. Events with no end last their DURATION, a
+// day when all-day, or no time at all.
+func Parse(r io.Reader, loc *time.Location) ([]Event, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var props []property
+	inCalendar, inEvent := false, false
+	depth := 0
+	for _, line := range lines {
+		p, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VCALENDAR"):
+			inCalendar = true
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT") && inCalendar && !inEvent:
+			inEvent, props, depth = true, nil, 0
+		case p.name == "BEGIN" && inEvent:
+			// Nested components such as VALARM are not part of the event.
+			depth++
+		case p.name == "END" && inEvent && depth > 0:
+			depth--
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT") && inEvent:
+			inEvent = false
+			if e, ok := buildEvent(props, loc); ok {
+				events = append(events, e)
+			}
+		case inEvent && depth == 0:
+			props = append(props, p)
+		}
+	}
+	if !inCalendar {
+		return nil, ErrNotCalendar
+	}
+	return events, nil
+}
+
+func buildEvent(props []property, loc *time.Location) (Event, bool) {
+	var e Event
+	hasStart, hasEnd := false, false
+	var duration *time.Duration
+	for _, p := range props {
+		switch p.name {
+		case "UID":
+			e.UID = strings.TrimSpace(p.value)
+		case "SUMMARY":
+			e.Summary = strings.TrimSpace(UnescapeText(p.value))
+		case "DESCRIPTION":
+			e.Description = strings.TrimSpace(UnescapeText(p.value))
+		case "CATEGORIES":
+			for _, c := range splitList(p.value) {
+				if c = strings.TrimSpace(UnescapeText(c)); c != "" {
+					e.Categories = append(e.Categories, c)
+				}
+			}
+		case "STATUS":
+			e.Status = strings.ToUpper(strings.TrimSpace(p.value))
+		case "RRULE":
+			e.RRule = strings.TrimSpace(p.value)
+		case "DTSTART":
+			t, allDay, err := parseTime(p, loc)
+			if err != nil {
+				return Event{}, false
+			}
+			e.Start, e.AllDay, hasStart = t, allDay, true
+		case "DTEND":
+			if t, _, err := parseTime(p, loc); err == nil {
+				e.End, hasEnd = t, true
+			}
+		case "DURATION":
+			if d, err := parseDuration(p.value); err == nil {
+				duration = &d
+			}
+		case "EXDATE":
+			for _, value := range strings.Split(p.value, ",") {
+				if t, _, err := parseTime(property{params: p.params, value: value}, loc); err == nil {
+					e.ExDates = append(e.ExDates, t)
+				}
+			}
+		case "RECURRENCE-ID":
+			if t, _, err := parseTime(p, loc); err == nil {
+				e.RecurrenceID = &t
+			}
+		case "LAST-MODIFIED":
+			if t, _, err := parseTime(p, loc); err == nil {
+				e.LastModified = t
+			}
+		}
+	}
+	if !hasStart {
+		return Event{}, false
+	}
+	if !hasEnd {
+		switch {
+		case duration != nil:
+			e.End = e.Start.Add(*duration)
+		case e.AllDay:
+			e.End = e.Start.AddDate(0, 0, 1)
+		default:
+			e.End = e.Start
+		}
+	}
+	return e, true
+}
@@ -0,0 +1,239 @@
+package ical
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// calendar wraps content lines in a VCALENDAR with CRLF line endings.
+func calendar(lines ...string) string {
+	all := append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...)
+	return strings.Join(append(all, "END:VCALENDAR"), "\r\n") + "\r\n"
+}
+
+// describe writes out the fields of an event that Parse fills in, with times
+// in RFC 3339, so that failures show what differs.
+func describe(e Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "uid=%q summary=%q description=%q categories=%q status=%q rrule=%q",
+		e.UID, e.Summary, e.Description, e.Categories, e.Status, e.RRule)
+	fmt.Fprintf(&b, " start=%s end=%s allDay=%v", e.Start.Format(time.RFC3339), e.End.Format(time.RFC3339), e.AllDay)
+	for _, ex := range e.ExDates {
+		fmt.Fprintf(&b, " exdate=%s", ex.Format(time.RFC3339))
+	}
+	if e.RecurrenceID != nil {
+		fmt.Fprintf(&b, " recurrenceId=%s", e.RecurrenceID.Format(time.RFC3339))
+	}
+	return b.String()
+}
+
+func TestParse(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	at := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  []Event
+	}{
+		{
+			name: "floating times with DTEND",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a", "SUMMARY:Standup",
+				"DTSTART:20260115T090000", "DTEND:20260115T091500",
+				"END:VEVENT",
+			},
+			want: []Event{{UID: "a", Summary: "Standup", Start: at(2026, 1, 15, 9, 0), End: at(2026, 1, 15, 9, 15)}},
+		},
+		{
+			name: "folded lines are unfolded",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				"SUMMARY:Quarterly plan", " ning review",
+				"DESCRIPTION:First line", "\tand the rest",
+				"DTSTART:20260115T090000",
+				"END:VEVENT",
+			},
+			want: []Event{{
+				UID: "a", Summary: "Quarterly planning review", Description: "First lineand the rest",
+				Start: at(2026, 1, 15, 9, 0), End: at(2026, 1, 15, 9, 0),
+			}},
+		},
+		{
+			name: "TZID is converted",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				"DTSTART;TZID=Europe/Berlin:20260310T090000",
+				"DTEND;TZID=\"Europe/Berlin\":20260310T100000",
+				"END:VEVENT",
+			},
+			// Berlin is still on CET while New York is already on EDT.
+			want: []Event{{UID: "a", Start: at(2026, 3, 10, 4, 0), End: at(2026, 3, 10, 5, 0)}},
+		},
+		{
+			name: "unknown TZID is floating",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				"DTSTART;TZID=Nowhere/Special:20260115T090000",
+				"END:VEVENT",
+			},
+			want: []Event{{UID: "a", Start: at(2026, 1, 15, 9, 0), End: at(2026, 1, 15, 9, 0)}},
+		},
+		{
+			name: "UTC is converted",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				"DTSTART:20260115T150000Z", "DTEND:20260115T163000Z",
+				"END:VEVENT",
+			},
+			want: []Event{{UID: "a", Start: at(2026, 1, 15, 10, 0), End: at(2026, 1, 15, 11, 30)}},
+		},
+		{
+			name: "all-day DATE lasts a day",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				"DTSTART;VALUE=DATE:20260115",
+				"END:VEVENT",
+				"BEGIN:VEVENT", "UID:b",
+				"DTSTART:20260120", "DTEND:20260122",
+				"END:VEVENT",
+			},
+			want: []Event{
+				{UID: "a", Start: at(2026, 1, 15, 0, 0), End: at(2026, 1, 16, 0, 0), AllDay: true},
+				{UID: "b", Start: at(2026, 1, 20, 0, 0), End: at(2026, 1, 22, 0, 0), AllDay: true},
+			},
+		},
+		{
+			name: "DURATION sets the end",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				"DTSTART:20260115T090000", "DURATION:PT1H30M",
+				"END:VEVENT",
+				"BEGIN:VEVENT", "UID:b",
+				"DTSTART:20260115T220000", "DURATION:P1DT2H",
+				"END:VEVENT",
+				"BEGIN:VEVENT", "UID:c",
+				"DTSTART:20260115T090000", "DURATION:PT",
+				"END:VEVENT",
+			},
+			want: []Event{
+				{UID: "a", Start: at(2026, 1, 15, 9, 0), End: at(2026, 1, 15, 10, 30)},
+				{UID: "b", Start: at(2026, 1, 15, 22, 0), End: at(2026, 1, 17, 0, 0)},
+				{UID: "c", Start: at(2026, 1, 15, 9, 0), End: at(2026, 1, 15, 9, 0)},
+			},
+		},
+		{
+			name: "recurring event with EXDATEs",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				"DTSTART;TZID=America/New_York:20260105T090000",
+				"DTEND;TZID=America/New_York:20260105T100000",
+				"RRULE:FREQ=WEEKLY;BYDAY=MO",
+				"EXDATE;TZID=America/New_York:20260112T090000,20260119T090000",
+				"EXDATE:20260126T140000Z",
+				"END:VEVENT",
+			},
+			want: []Event{{
+				UID: "a", RRule: "FREQ=WEEKLY;BYDAY=MO",
+				Start: at(2026, 1, 5, 9, 0), End: at(2026, 1, 5, 10, 0),
+				ExDates: []time.Time{at(2026, 1, 12, 9, 0), at(2026, 1, 19, 9, 0), at(2026, 1, 26, 9, 0)},
+			}},
+		},
+		{
+			name: "RECURRENCE-ID override",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a", "SUMMARY:Moved",
+				"RECURRENCE-ID;TZID=America/New_York:20260112T090000",
+				"DTSTART;TZID=America/New_York:20260113T110000",
+				"DTEND;TZID=America/New_York:20260113T120000",
+				"END:VEVENT",
+			},
+			want: []Event{func() Event {
+				id := at(2026, 1, 12, 9, 0)
+				return Event{UID: "a", Summary: "Moved", Start: at(2026, 1, 13, 11, 0), End: at(2026, 1, 13, 12, 0), RecurrenceID: &id}
+			}()},
+		},
+		{
+			name: "nested VALARM is skipped",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a", "DESCRIPTION:Bring slides",
+				"DTSTART:20260115T090000",
+				"BEGIN:VALARM", "ACTION:DISPLAY", "DESCRIPTION:Reminder", "TRIGGER:-PT15M",
+				"DTSTART:20260101T000000",
+				"END:VALARM",
+				"DTEND:20260115T100000",
+				"END:VEVENT",
+			},
+			want: []Event{{UID: "a", Description: "Bring slides", Start: at(2026, 1, 15, 9, 0), End: at(2026, 1, 15, 10, 0)}},
+		},
+		{
+			name: "escaped text and quoted parameters",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a",
+				`SUMMARY:Lunch\, then review\; maybe`,
+				`DESCRIPTION;ALTREP="cid:part1@example.org":Line one\nLine two \\ end`,
+				`CATEGORIES:Work,Ops\, infra, ,Team`,
+				"status:cancelled",
+				"DTSTART:20260115T120000",
+				"END:VEVENT",
+			},
+			want: []Event{{
+				UID: "a", Summary: "Lunch, then review; maybe", Description: "Line one\nLine two \\ end",
+				Categories: []string{"Work", "Ops, infra", "Team"}, Status: "CANCELLED",
+				Start: at(2026, 1, 15, 12, 0), End: at(2026, 1, 15, 12, 0),
+			}},
+		},
+		{
+			name: "events without a usable DTSTART are dropped",
+			lines: []string{
+				"BEGIN:VEVENT", "UID:a", "SUMMARY:No start", "END:VEVENT",
+				"BEGIN:VEVENT", "UID:b", "DTSTART:tomorrow", "END:VEVENT",
+				"BEGIN:VEVENT", "UID:c", "DTSTART:20260115T090000", "END:VEVENT",
+			},
+			want: []Event{{UID: "c", Start: at(2026, 1, 15, 9, 0), End: at(2026, 1, 15, 9, 0)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := Parse(strings.NewReader(calendar(tt.lines...)), loc)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if len(events) != len(tt.want) {
+				t.Fatalf("got %d events, want %d", len(events), len(tt.want))
+			}
+			for i := range events {
+				if got, want := describe(events[i]), describe(tt.want[i]); got != want {
+					t.Errorf("event %d:\n got %s\nwant %s", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseIgnoresEventsBeforeTheCalendar(t *testing.T) {
+	input := "BEGIN:VEVENT\r\nUID:a\r\nDTSTART:20260115T090000\r\nEND:VEVENT\r\n" + calendar()
+	events, err := Parse(strings.NewReader(input), time.UTC)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("got %d events, want none", len(events))
+	}
+}
+
+func TestParseRejectsNonCalendars(t *testing.T) {
+	for _, input := range []string{"", "hello world", "BEGIN:VCARD\r\nFN:Jane\r\nEND:VCARD\r\n"} {
+		if _, err := Parse(strings.NewReader(input), time.UTC); !errors.Is(err, ErrNotCalendar) {
+			t.Errorf("Parse(%q) error = %v, want ErrNotCalendar", input, err)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package ical reads and writes RFC 5545 calendars. Calendars are written
+// with timed events, optionally recurring, in a single named timezone, with
+// the VTIMEZONE block that calendar apps need to place them correctly across
+// DST changes. Turning parsed events into schedule items is left to the
+// scheduleimport package.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	localLayout = "20060102T150405"
+	utcLayout   = "20060102T150405Z"
+	dateLayout  = "20060102"
+
+	// maxLineOctets is the longest a content line may be before folding.
+	maxLineOctets = 75
+)
+
+// Event is a VEVENT. Start and End are wall-clock times in the calendar's
+// location. RRule is an RRULE value without the "RRULE:" prefix. ExDates,
+// RecurrenceID and Status are only read, never written.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Categories   []string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+	Status       string
+	LastModified time.Time
+}
+
+// Calendar is a VCALENDAR whose events all share Location.
+type Calendar struct {
+	ProdID   string
+	Name     string
+	Location *time.Location
+	Events   []Event
+}
+
+// EscapeText escapes a TEXT value.
+func EscapeText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, ";", `\;`)
+	s = strings.ReplaceAll(s, ",", `\,`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+type lineWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes one content line, folding it at 75 octets without splitting
+// a UTF-8 sequence.
+func (lw *lineWriter) line(name, value string) {
+	s := name + ":" + value
+	first := true
+	for len(s) > 0 && lw.err == nil {
+		limit := maxLineOctets
+		if !first {
+			limit--
+			lw.w.WriteByte(' ')
+		}
+		cut := len(s)
+		if cut > limit {
+			cut = limit
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+		}
+		_, lw.err = lw.w.WriteString(s[:cut] + "\r\n")
+		s = s[cut:]
+		first = false
+	}
+}
+
+func offset(seconds int) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds/60%60)
+}
+
+// yearlyRule describes the yearly recurrence of a transition at local time
+// t, such as the second Sunday of March or the last Sunday of October.
+func yearlyRule(t time.Time) string {
+	n := (t.Day()-1)/7 + 1
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if t.Day()+7 > last {
+		n = -1
+	}
+	day := strings.ToUpper(t.Weekday().String()[:2])
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%d%s", int(t.Month()), n, day)
+}
+
+// writeObservance writes the STANDARD or DAYLIGHT block for the zone in
+// force at t, which took over from an offset of previous seconds at start.
+func (lw *lineWriter) writeObservance(t, start time.Time, previous int, recurring bool) {
+	name, current := t.Zone()
+	kind := "STANDARD"
+	if t.IsDST() {
+		kind = "DAYLIGHT"
+	}
+	// DTSTART of an observance is the local time it begins, read in the
+	// offset being left.
+	onset := start.UTC().Add(time.Duration(previous) * time.Second)
+	lw.line("BEGIN", kind)
+	lw.line("DTSTART", onset.Format(localLayout))
+	if recurring {
+		lw.line("RRULE", yearlyRule(onset))
+	}
+	lw.line("TZOFFSETFROM", offset(previous))
+	lw.line("TZOFFSETTO", offset(current))
+	lw.line("TZNAME", EscapeText(name))
+	lw.line("END", kind)
+}
+
+// writeTimezone writes a VTIMEZONE for loc. Offset changes between from and
+// to are listed as they happened; the two after to are written as yearly
+// rules, so recurring events stay right beyond the span. Zones without
+// changes get a single STANDARD block.
+func (lw *lineWriter) writeTimezone(loc *time.Location, from, to time.Time) {
+	lw.line("BEGIN", "VTIMEZONE")
+	lw.line("TZID", loc.String())
+
+	t := from.In(loc)
+	_, current := t.Zone()
+	start, end := t.ZoneBounds()
+	previous := current
+	if !start.IsZero() {
+		_, previous = start.Add(-time.Second).Zone()
+	} else {
+		start = time.Date(1970, 1, 1, 0, 0, 0, 0, loc)
+	}
+	rules := 0
+	for {
+		recurring := !end.IsZero() && start.After(to)
+		lw.writeObservance(t, start, previous, recurring)
+		if recurring {
+			rules++
+		}
+		if end.IsZero() || rules == 2 {
+			break
+		}
+		t = end.In(loc)
+		previous = current
+		_, current = t.Zone()
+		start, end = t.ZoneBounds()
+	}
+	lw.line("END", "VTIMEZONE")
+}
+
+func (lw *lineWriter) writeEvent(e Event, tzid string, stamp time.Time) {
+	lw.line("BEGIN", "VEVENT")
+	lw.line("UID", e.UID)
+	lw.line("DTSTAMP", stamp.UTC().Format(utcLayout))
+	if e.AllDay {
+		lw.line("DTSTART;VALUE=DATE", e.Start.Format(dateLayout))
+		lw.line("DTEND;VALUE=DATE", e.End.Format(dateLayout))
+	} else {
+		lw.line("DTSTART;TZID="+tzid, e.Start.Format(localLayout))
+		lw.line("DTEND;TZID="+tzid, e.End.Format(localLayout))
+	}
+	if e.RRule != "" {
+		lw.line("RRULE", e.RRule)
+	}
+	lw.line("SUMMARY", EscapeText(e.Summary))
+	if e.Description != "" {
+		lw.line("DESCRIPTION", EscapeText(e.Description))
+	}
+	if len(e.Categories) > 0 {
+		categories := make([]string, len(e.Categories))
+		for i, c := range e.Categories {
+			categories[i] = EscapeText(c)
+		}
+		lw.line("CATEGORIES", strings.Join(categories, ","))
+	}
+	if !e.LastModified.IsZero() {
+		lw.line("LAST-MODIFIED", e.LastModified.UTC().Format(utcLayout))
+	}
+	lw.line("END", "VEVENT")
+}
+
+// Encode writes the calendar to w, with a VTIMEZONE covering the span of
+// the events.
+func (c *Calendar) Encode(w io.Writer) error {
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	stamp := time.Now()
+	from, to := stamp, stamp
+	for _, e := range c.Events {
+		if e.Start.Before(from) {
+			from = e.Start
+		}
+		if e.End.After(to) {
+			to = e.End
+		}
+	}
+
+	lw := &lineWriter{w: bufio.NewWriter(w)}
+	lw.line("BEGIN", "VCALENDAR")
+	lw.line("VERSION", "2.0")
+	lw.line("PRODID", c.ProdID)
+	lw.line("CALSCALE", "GREGORIAN")
+	lw.line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME", EscapeText(c.Name))
+	}
+	lw.line("X-WR-TIMEZONE", loc.String())
+	lw.writeTimezone(loc, from, to)
+	for _, e := range c.Events {
+		lw.writeEvent(e, loc.String(), stamp)
+	}
+	lw.line("END", "VCALENDAR")
+	if lw.err != nil {
+		return lw.err
+	}
+	return lw.w.Flush()
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FocusSessionStatus string
+
+const (
+	FocusSessionActive      FocusSessionStatus = "active"
+	FocusSessionCompleted   FocusSessionStatus = "completed"
+	FocusSessionInterrupted FocusSessionStatus = "interrupted"
+)
+
+type InterruptionReason string
+
+const (
+	InterruptionDistracted InterruptionReason = "distracted"
+	InterruptionExternal   InterruptionReason = "external"
+	InterruptionMeeting    InterruptionReason = "meeting"
+	InterruptionUrgent     InterruptionReason = "urgent"
+	InterruptionTired      InterruptionReason = "tired"
+	InterruptionOther      InterruptionReason = "other"
+)
+
+func (r InterruptionReason) IsValid() bool {
+	switch r {
+	case InterruptionDistracted, InterruptionExternal, InterruptionMeeting,
+		InterruptionUrgent, InterruptionTired, InterruptionOther:
+		return true
+	}
+	return false
+}
+
+// FocusSettings are a user's Pomodoro lengths in minutes. A long break
+// replaces the short one after every LongBreakEvery completed sessions.
+type FocusSettings struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	User              primitive.ObjectID `bson:"user" json:"user"`
+	WorkMinutes       int                `bson:"workMinutes" json:"workMinutes"`
+	ShortBreakMinutes int                `bson:"shortBreakMinutes" json:"shortBreakMinutes"`
+	LongBreakMinutes  int                `bson:"longBreakMinutes" json:"longBreakMinutes"`
+	LongBreakEvery    int                `bson:"longBreakEvery" json:"longBreakEvery"`
+	UpdatedAt         time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// DefaultFocusSettings are the classic Pomodoro lengths.
+var DefaultFocusSettings = FocusSettings{
+	WorkMinutes:       25,
+	ShortBreakMinutes: 5,
+	LongBreakMinutes:  15,
+	LongBreakEvery:    4,
+}
+
+// FocusSession is one work interval, optionally tied to a schedule item or a
+// skill. Date is the day the session started on, in the user's timezone.
+type FocusSession struct {
+	ID                 primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	User               primitive.ObjectID  `bson:"user" json:"user"`
+	Date               time.Time           `bson:"date" json:"date"`
+	Category           string              `bson:"category" json:"category"`
+	ScheduleID         *primitive.ObjectID `bson:"scheduleId,omitempty" json:"scheduleId,omitempty"`
+	ScheduleItemID     *primitive.ObjectID `bson:"scheduleItemId,omitempty" json:"scheduleItemId,omitempty"`
+	SkillID            *primitive.ObjectID `bson:"skillId,omitempty" json:"skillId,omitempty"`
+	Status             FocusSessionStatus  `bson:"status" json:"status"`
+	WorkMinutes        int                 `bson:"workMinutes" json:"workMinutes"`
+	BreakMinutes       int                 `bson:"breakMinutes" json:"breakMinutes"`
+	StartedAt          time.Time           `bson:"startedAt" json:"startedAt"`
+	EndedAt            *time.Time          `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	FocusedMinutes     float64             `bson:"focusedMinutes" json:"focusedMinutes"`
+	InterruptionReason InterruptionReason  `bson:"interruptionReason,omitempty" json:"interruptionReason,omitempty"`
+	InterruptionNote   string              `bson:"interruptionNote,omitempty" json:"interruptionNote,omitempty"`
+	CreatedAt          time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt          time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
@@ -1,21 +1,35 @@
 package models
 
 import (
+	"errors"
+	"sort"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ScheduleItem struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"` // Use ObjectID if needed, or just embed
-	Title       string             `bson:"title" json:"title"`
-	Description string             `bson:"description,omitempty" json:"description,omitempty"`
-	StartTime   string             `bson:"startTime" json:"startTime"`
-	EndTime     string             `bson:"endTime" json:"endTime"`
-	Category    string             `bson:"category" json:"category"`
-	Priority    string             `bson:"priority" json:"priority"`
-	Completed   bool               `bson:"completed" json:"completed"`
-	Notes       string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"` // Use ObjectID if needed, or just embed
+	Title       string              `bson:"title" json:"title"`
+	Description string              `bson:"description,omitempty" json:"description,omitempty"`
+	StartTime   string              `bson:"startTime" json:"startTime"`
+	EndTime     string              `bson:"endTime" json:"endTime"`
+	Category    string              `bson:"category" json:"category"`
+	Priority    string              `bson:"priority" json:"priority"`
+	Completed   bool                `bson:"completed" json:"completed"`
+	Notes       string              `bson:"notes,omitempty" json:"notes,omitempty"`
+	SkillID     *primitive.ObjectID `bson:"skillId,omitempty" json:"skillId,omitempty"`
+	ImportUID   string              `bson:"importUid,omitempty" json:"importUid,omitempty"`
+	Origin      *ScheduleItemOrigin `bson:"origin,omitempty" json:"origin,omitempty"`
+}
+
+// ScheduleItemOrigin links a rolled-over item to the item it was first
+// planned as. Rolling it over again keeps the original link.
+type ScheduleItemOrigin struct {
+	ScheduleID primitive.ObjectID `bson:"scheduleId" json:"scheduleId"`
+	ItemID     primitive.ObjectID `bson:"itemId" json:"itemId"`
+	Date       time.Time          `bson:"date" json:"date"`
 }
 
 type ScheduleStatus string
@@ -34,6 +48,143 @@ type Schedule struct {
 	Items      []ScheduleItem     `bson:"items" json:"items"`
 	TotalHours float64            `bson:"totalHours" json:"totalHours"`
 	Status     ScheduleStatus     `bson:"status" json:"status"`
-	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// TemplateID links an occurrence to the template that produced it.
+	// Modified is set once the occurrence is edited, after which template
+	// changes no longer reach it.
+	TemplateID *primitive.ObjectID `bson:"templateId,omitempty" json:"templateId,omitempty"`
+	Modified   bool                `bson:"modified,omitempty" json:"modified,omitempty"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+const MinutesPerDay = 24 * 60
+
+// ClockMinutes parses an "HH:MM" time into minutes after midnight.
+func ClockMinutes(value string) (int, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// Window returns the item's start and end in minutes after midnight. Items
+// live within their own day, so the end must come after the start; anything
+// running past midnight belongs on the next day's schedule. An end of 00:00
+// or 24:00 is the end of the day, as in 23:00-00:00.
+func (i ScheduleItem) Window() (int, int, error) {
+	start, ok := ClockMinutes(i.StartTime)
+	if !ok {
+		return 0, 0, errors.New("start time must be in HH:MM format")
+	}
+	end, ok := ClockMinutes(i.EndTime)
+	if strings.TrimSpace(i.EndTime) == "24:00" {
+		end, ok = MinutesPerDay, true
+	}
+	if !ok {
+		return 0, 0, errors.New("end time must be in HH:MM format")
+	}
+	if end == 0 {
+		end = MinutesPerDay
+	}
+	if end <= start {
+		return 0, 0, errors.New("end time must be after start time")
+	}
+	return start, end, nil
+}
+
+// ItemOverlap is a pair of items on one schedule whose windows overlap.
+type ItemOverlap struct {
+	Item ScheduleItem
+	With ScheduleItem
+}
+
+// FindOverlaps lists each pair of items whose windows overlap. Items that
+// merely touch, one ending as the next starts, do not conflict. Items with
+// invalid times are left to validation.
+func FindOverlaps(items []ScheduleItem) []ItemOverlap {
+	type window struct {
+		item       ScheduleItem
+		start, end int
+	}
+	windows := []window{}
+	for _, item := range items {
+		if start, end, err := item.Window(); err == nil {
+			windows = append(windows, window{item, start, end})
+		}
+	}
+	sort.SliceStable(windows, func(i, j int) bool { return windows[i].start < windows[j].start })
+
+	overlaps := []ItemOverlap{}
+	for i := range windows {
+		for j := i + 1; j < len(windows) && windows[j].start < windows[i].end; j++ {
+			overlaps = append(overlaps, ItemOverlap{Item: windows[i].item, With: windows[j].item})
+		}
+	}
+	return overlaps
+}
+
+func ScheduleDayType(day time.Time) string {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return "Weekend"
+	}
+	return "Weekday"
+}
+
+// CalculateStats sets TotalHours and Status from the items. Items with
+// unreadable or inverted times predate validation and add no hours.
+func (s *Schedule) CalculateStats() {
+	var totalHours float64
+	completedItems := 0
+
+	for _, item := range s.Items {
+		if start, end, err := item.Window(); err == nil {
+			totalHours += float64(end-start) / 60
+		}
+		if item.Completed {
+			completedItems++
+		}
+	}
+
+	s.TotalHours = totalHours
+
+	if len(s.Items) > 0 {
+		completionPercentage := (float64(completedItems) / float64(len(s.Items))) * 100
+		if completionPercentage == 0 {
+			s.Status = ScheduleStatusPlanned
+		} else if completionPercentage < 100 {
+			s.Status = ScheduleStatusInProgress
+		} else {
+			s.Status = ScheduleStatusCompleted
+		}
+	} else {
+		s.Status = ScheduleStatusPlanned
+	}
+}
+
+// MergeSchedules folds schedules that fall on the same day into the first,
+// appending the items it does not already hold. A template occurrence that
+// gains items from elsewhere counts as edited, so template changes no longer
+// overwrite it.
+func MergeSchedules(schedules []Schedule) Schedule {
+	kept := schedules[0]
+	seen := map[primitive.ObjectID]bool{}
+	for _, item := range kept.Items {
+		seen[item.ID] = true
+	}
+	for _, duplicate := range schedules[1:] {
+		for _, item := range duplicate.Items {
+			if seen[item.ID] {
+				continue
+			}
+			seen[item.ID] = true
+			kept.Items = append(kept.Items, item)
+			if kept.TemplateID != nil {
+				kept.Modified = true
+			}
+		}
+	}
+	kept.CalculateStats()
+	kept.UpdatedAt = time.Now()
+	return kept
 }
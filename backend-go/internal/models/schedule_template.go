@@ -0,0 +1,84 @@
+package models
+
+import (
+	"crypto/sha256"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScheduleExceptionType string
+
+const (
+	ScheduleExceptionSkip   ScheduleExceptionType = "skip"
+	ScheduleExceptionModify ScheduleExceptionType = "modify"
+)
+
+// ScheduleException overrides one occurrence of a template: a skipped date
+// gets no schedule and a modified one gets Items instead of the template's.
+type ScheduleException struct {
+	Date  time.Time             `bson:"date" json:"date"`
+	Type  ScheduleExceptionType `bson:"type" json:"type"`
+	Items []ScheduleItem        `bson:"items,omitempty" json:"items,omitempty"`
+}
+
+// ScheduleTemplate is a recurring schedule. RRule is an RFC 5545 rule such
+// as "FREQ=WEEKLY;BYDAY=MO,WE,FR", counted from StartDate. Occurrences are
+// stored as ordinary schedules that point back at the template.
+type ScheduleTemplate struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	User       primitive.ObjectID  `bson:"user" json:"user"`
+	Name       string              `bson:"name" json:"name"`
+	RRule      string              `bson:"rrule" json:"rrule"`
+	StartDate  time.Time           `bson:"startDate" json:"startDate"`
+	Items      []ScheduleItem      `bson:"items" json:"items"`
+	Exceptions []ScheduleException `bson:"exceptions" json:"exceptions"`
+	CreatedAt  time.Time           `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time           `bson:"updatedAt" json:"updatedAt"`
+}
+
+// Exception returns the exception for day, if there is one.
+func (t *ScheduleTemplate) Exception(day time.Time) *ScheduleException {
+	for i := range t.Exceptions {
+		if t.Exceptions[i].Date.Equal(day) {
+			return &t.Exceptions[i]
+		}
+	}
+	return nil
+}
+
+// OccurrenceItemID returns the ID a template item has in the occurrence on
+// day. It is derived from the two, so that rewriting an occurrence after a
+// template edit keeps the IDs that feeds, focus sessions and clients hold.
+// The leading timestamp bytes are those of the template item.
+func OccurrenceItemID(templateItemID primitive.ObjectID, day time.Time) primitive.ObjectID {
+	if templateItemID.IsZero() {
+		return primitive.NewObjectID()
+	}
+	sum := sha256.Sum256(append(templateItemID[:], day.Format("2006-01-02")...))
+	var id primitive.ObjectID
+	copy(id[:4], templateItemID[:4])
+	copy(id[4:], sum[:])
+	return id
+}
+
+// ItemsOn returns the items an occurrence on day should have, or false when
+// the occurrence is skipped. Each item's ID comes from OccurrenceItemID, so
+// occurrences never share IDs and the same occurrence always gets the same
+// ones.
+func (t *ScheduleTemplate) ItemsOn(day time.Time) ([]ScheduleItem, bool) {
+	source := t.Items
+	if exception := t.Exception(day); exception != nil {
+		if exception.Type == ScheduleExceptionSkip {
+			return nil, false
+		}
+		source = exception.Items
+	}
+	items := make([]ScheduleItem, len(source))
+	for i, item := range source {
+		item.ID = OccurrenceItemID(item.ID, day)
+		item.Completed = false
+		items[i] = item
+	}
+	return items, true
+}
@@ -17,10 +17,22 @@ const (
 	ResourceTypeOther         ResourceType = "other"
 )
 
+type ResourceStatus string
+
+const (
+	ResourceStatusNotStarted ResourceStatus = "not-started"
+	ResourceStatusInProgress ResourceStatus = "in-progress"
+	ResourceStatusDone       ResourceStatus = "done"
+)
+
 type Resource struct {
-	Title string       `bson:"title" json:"title"`
-	URL   string       `bson:"url" json:"url"`
-	Type  ResourceType `bson:"type" json:"type"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title            string             `bson:"title" json:"title"`
+	URL              string             `bson:"url" json:"url"`
+	Type             ResourceType       `bson:"type" json:"type"`
+	Status           ResourceStatus     `bson:"status,omitempty" json:"status,omitempty"`
+	TimeSpentMinutes int                `bson:"timeSpentMinutes,omitempty" json:"timeSpentMinutes,omitempty"`
+	Rating           *int               `bson:"rating,omitempty" json:"rating,omitempty"` // 1-5
 }
 
 type SkillStatus string
@@ -39,6 +51,16 @@ const (
 	SkillPriorityLow    SkillPriority = "low"
 )
 
+// SkillMilestone is a checkpoint on the way to a skill's target date.
+// Milestones are kept in the order they should be reached.
+type SkillMilestone struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title       string             `bson:"title" json:"title"`
+	DueDate     *time.Time         `bson:"dueDate,omitempty" json:"dueDate,omitempty"`
+	Completed   bool               `bson:"completed" json:"completed"`
+	CompletedAt *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+}
+
 type Skill struct {
 	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	User           primitive.ObjectID `bson:"user" json:"user"`
@@ -47,11 +69,19 @@ type Skill struct {
 	Status         SkillStatus        `bson:"status" json:"status"`
 	StartDate      *time.Time         `bson:"startDate,omitempty" json:"startDate,omitempty"`
 	CompletionDate *time.Time         `bson:"completionDate,omitempty" json:"completionDate,omitempty"`
+	TargetDate     *time.Time         `bson:"targetDate,omitempty" json:"targetDate,omitempty"`
 	Progress       int                `bson:"progress" json:"progress"`
 	Description    string             `bson:"description,omitempty" json:"description,omitempty"`
 	Resources      []Resource         `bson:"resources" json:"resources"`
 	Priority       SkillPriority      `bson:"priority" json:"priority"`
 	OrderIndex     int                `bson:"orderIndex" json:"orderIndex"`
-	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// ProgressFromResources keeps Progress at the share of resources done.
+	ProgressFromResources bool                 `bson:"progressFromResources,omitempty" json:"progressFromResources,omitempty"`
+	Prerequisites         []primitive.ObjectID `bson:"prerequisites,omitempty" json:"prerequisites,omitempty"`
+	AutoStart             bool                 `bson:"autoStart,omitempty" json:"autoStart,omitempty"`
+	ReviewEnabled         bool                 `bson:"reviewEnabled,omitempty" json:"reviewEnabled,omitempty"`
+	Review                *SkillReview         `bson:"review,omitempty" json:"review,omitempty"`
+	Milestones            []SkillMilestone     `bson:"milestones,omitempty" json:"milestones,omitempty"`
+	CreatedAt             time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt             time.Time            `bson:"updatedAt" json:"updatedAt"`
 }
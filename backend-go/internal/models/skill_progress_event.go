@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SkillProgressEvent records one change to a skill's status or progress.
+// A skill's creation is recorded with empty From values.
+type SkillProgressEvent struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User         primitive.ObjectID `bson:"user" json:"user"`
+	Skill        primitive.ObjectID `bson:"skill" json:"skill"`
+	Category     string             `bson:"category" json:"category"`
+	FromStatus   SkillStatus        `bson:"fromStatus,omitempty" json:"fromStatus,omitempty"`
+	ToStatus     SkillStatus        `bson:"toStatus" json:"toStatus"`
+	FromProgress int                `bson:"fromProgress" json:"fromProgress"`
+	ToProgress   int                `bson:"toProgress" json:"toProgress"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recall quality is self-rated on SM-2's 0-5 scale, where 3 and above means
+// the skill was remembered.
+const (
+	MinRecallQuality  = 0
+	MaxRecallQuality  = 5
+	PassRecallQuality = 3
+)
+
+const (
+	initialEaseFactor = 2.5
+	minEaseFactor     = 1.3
+)
+
+// SkillReview is the spaced-repetition schedule of a completed skill, kept
+// with the SM-2 algorithm. NextReview is a day at midnight in the user's
+// timezone.
+type SkillReview struct {
+	EaseFactor   float64    `bson:"easeFactor" json:"easeFactor"`
+	IntervalDays int        `bson:"intervalDays" json:"intervalDays"`
+	Repetitions  int        `bson:"repetitions" json:"repetitions"`
+	NextReview   time.Time  `bson:"nextReview" json:"nextReview"`
+	LastReviewed *time.Time `bson:"lastReviewed,omitempty" json:"lastReviewed,omitempty"`
+}
+
+// NewSkillReview starts the schedule for a skill completed on completedDay,
+// with the first review due the day after.
+func NewSkillReview(completedDay time.Time) SkillReview {
+	return SkillReview{
+		EaseFactor:   initialEaseFactor,
+		IntervalDays: 1,
+		NextReview:   completedDay.AddDate(0, 0, 1),
+	}
+}
+
+// ReviewFor works out the review schedule a skill should have after a change
+// to its status or review setting. It returns the value to store and whether
+// it differs from what the skill has: a skill that is newly completed, or has
+// reviews turned on while completed, starts a fresh schedule from the day it
+// was completed, and one that is not completed or has reviews off has none.
+func ReviewFor(before Skill, status SkillStatus, enabled bool, loc *time.Location) (*SkillReview, bool) {
+	if !enabled || status != SkillStatusCompleted {
+		return nil, before.Review != nil
+	}
+	if before.Review != nil && before.Status == SkillStatusCompleted {
+		return before.Review, false
+	}
+	completed := time.Now()
+	if before.Status == SkillStatusCompleted && before.CompletionDate != nil {
+		completed = *before.CompletionDate
+	}
+	review := NewSkillReview(timeutil.StartOfDay(completed, loc))
+	return &review, true
+}
+
+// Record applies a review of the given quality made on day. A pass grows the
+// interval (1 day, 6 days, then by the ease factor); a failure starts the
+// repetitions over. The ease factor moves with every review either way.
+func (r *SkillReview) Record(quality int, day time.Time) {
+	if quality >= PassRecallQuality {
+		switch r.Repetitions {
+		case 0:
+			r.IntervalDays = 1
+		case 1:
+			r.IntervalDays = 6
+		default:
+			r.IntervalDays = int(math.Round(float64(r.IntervalDays) * r.EaseFactor))
+		}
+		r.Repetitions++
+	} else {
+		r.Repetitions = 0
+		r.IntervalDays = 1
+	}
+
+	miss := float64(MaxRecallQuality - quality)
+	r.EaseFactor = math.Max(minEaseFactor, r.EaseFactor+0.1-miss*(0.08+miss*0.02))
+	r.NextReview = day.AddDate(0, 0, r.IntervalDays)
+	r.LastReviewed = &day
+}
+
+// SkillReviewEvent records one review submission and the schedule it led to.
+type SkillReviewEvent struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User         primitive.ObjectID `bson:"user" json:"user"`
+	Skill        primitive.ObjectID `bson:"skill" json:"skill"`
+	Quality      int                `bson:"quality" json:"quality"`
+	IntervalDays int                `bson:"intervalDays" json:"intervalDays"`
+	EaseFactor   float64            `bson:"easeFactor" json:"easeFactor"`
+	NextReview   time.Time          `bson:"nextReview" json:"nextReview"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -0,0 +1,227 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// skillTransitions lists the statuses each status may move to. A skill can
+// be completed without being started, and an unstarted one can be put back,
+// but a completed skill has to be reopened before it is upcoming again.
+var skillTransitions = map[SkillStatus][]SkillStatus{
+	SkillStatusUpcoming:   {SkillStatusInProgress, SkillStatusCompleted},
+	SkillStatusInProgress: {SkillStatusUpcoming, SkillStatusCompleted},
+	SkillStatusCompleted:  {SkillStatusInProgress},
+}
+
+func (s SkillStatus) IsValid() bool {
+	_, ok := skillTransitions[s]
+	return ok
+}
+
+// CanMoveTo reports whether a skill in status s may change to status to.
+// Staying in the same status is always allowed.
+func (s SkillStatus) CanMoveTo(to SkillStatus) bool {
+	if s == to {
+		return true
+	}
+	for _, allowed := range skillTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSkillProgress enforces the invariant between status and progress: an
+// upcoming skill has no progress, one in progress is short of 100%, and a
+// completed one is at 100%.
+func CheckSkillProgress(status SkillStatus, progress int) error {
+	if progress < 0 || progress > 100 {
+		return errors.New("progress must be between 0 and 100")
+	}
+	switch status {
+	case SkillStatusUpcoming:
+		if progress != 0 {
+			return errors.New("an upcoming skill cannot have progress; start it or set progress to 0")
+		}
+	case SkillStatusInProgress:
+		if progress == 100 {
+			return errors.New("an in-progress skill cannot be at 100% progress; complete it instead")
+		}
+	case SkillStatusCompleted:
+		if progress != 100 {
+			return errors.New("a completed skill must be at 100% progress")
+		}
+	default:
+		return fmt.Errorf("unknown status %q", status)
+	}
+	return nil
+}
+
+// Transition moves the skill to status and progress at now. It rejects
+// moves the state machine does not allow and combinations that break the
+// progress invariant, and keeps StartDate and CompletionDate in step: an
+// upcoming skill has neither, a started one keeps the day it was first
+// started, and only a completed one has a completion date. A new skill,
+// with no status yet, may start in any status.
+func (s *Skill) Transition(status SkillStatus, progress int, now time.Time) error {
+	if !status.IsValid() {
+		return fmt.Errorf("unknown status %q", status)
+	}
+	if s.Status != "" && !s.Status.CanMoveTo(status) {
+		return fmt.Errorf("a %s skill cannot move to %s", s.Status, status)
+	}
+	if err := CheckSkillProgress(status, progress); err != nil {
+		return err
+	}
+
+	s.Status = status
+	s.Progress = progress
+	switch status {
+	case SkillStatusUpcoming:
+		s.StartDate = nil
+		s.CompletionDate = nil
+	case SkillStatusInProgress:
+		if s.StartDate == nil {
+			s.StartDate = &now
+		}
+		s.CompletionDate = nil
+	case SkillStatusCompleted:
+		if s.CompletionDate == nil {
+			s.CompletionDate = &now
+		}
+	}
+	return nil
+}
+
+// ProgressStatus is the status an open skill whose progress is derived from
+// its resources should be in at progress: started once any resource is done,
+// and completed when all of them are. Completed skills stay completed.
+func ProgressStatus(status SkillStatus, progress int) SkillStatus {
+	switch {
+	case status == SkillStatusCompleted:
+		return status
+	case progress >= 100:
+		return SkillStatusCompleted
+	case progress > 0:
+		return SkillStatusInProgress
+	default:
+		return status
+	}
+}
+
+// ResourceProgress is the share of resources marked done, as a percentage.
+func ResourceProgress(resources []Resource) int {
+	if len(resources) == 0 {
+		return 0
+	}
+	done := 0
+	for _, resource := range resources {
+		if resource.Status == ResourceStatusDone {
+			done++
+		}
+	}
+	return done * 100 / len(resources)
+}
+
+// SyncResourceProgress keeps an open skill whose progress is derived from
+// its resources in step with them at now: progress is recomputed, the status
+// follows ProgressStatus and the review schedule follows ReviewFor, with
+// days in loc. A completed skill keeps its progress. It reports whether the
+// skill's progress is derived, that is whether its state may have changed.
+func (s *Skill) SyncResourceProgress(now time.Time, loc *time.Location) (bool, error) {
+	if !s.ProgressFromResources || s.Status == SkillStatusCompleted {
+		return false, nil
+	}
+	before := *s
+	progress := ResourceProgress(s.Resources)
+	if err := s.Transition(ProgressStatus(s.Status, progress), progress, now); err != nil {
+		return false, err
+	}
+	if review, changed := ReviewFor(before, s.Status, s.ReviewEnabled, loc); changed {
+		s.Review = review
+	}
+	return true, nil
+}
+
+// Normalize repairs a stored skill that breaks the status invariants and
+// describes each fix. The status is trusted over the progress, except that
+// recorded progress on an upcoming skill, or full progress on one in
+// progress, is taken as evidence it moved on. An unknown status is inferred
+// from the progress.
+func (s *Skill) Normalize(now time.Time) []string {
+	var fixes []string
+	fix := func(format string, args ...interface{}) {
+		fixes = append(fixes, fmt.Sprintf(format, args...))
+	}
+
+	if s.Progress < 0 || s.Progress > 100 {
+		clamped := min(max(s.Progress, 0), 100)
+		fix("progress %d clamped to %d", s.Progress, clamped)
+		s.Progress = clamped
+	}
+	if !s.Status.IsValid() {
+		status := ProgressStatus(SkillStatusUpcoming, s.Progress)
+		fix("unknown status %q set to %s", s.Status, status)
+		s.Status = status
+	}
+	if s.Status != SkillStatusCompleted {
+		if status := ProgressStatus(s.Status, s.Progress); status != s.Status {
+			fix("%s skill at %d%% progress moved to %s", s.Status, s.Progress, status)
+			s.Status = status
+		}
+	}
+
+	// Dates missing from a started or completed skill are filled from when
+	// the document was last known to change.
+	known := s.UpdatedAt
+	if known.IsZero() {
+		known = now
+	}
+	switch s.Status {
+	case SkillStatusUpcoming:
+		if s.StartDate != nil {
+			fix("start date cleared from upcoming skill")
+			s.StartDate = nil
+		}
+		if s.CompletionDate != nil {
+			fix("completion date cleared from upcoming skill")
+			s.CompletionDate = nil
+		}
+	case SkillStatusInProgress:
+		if s.StartDate == nil {
+			start := s.CreatedAt
+			if start.IsZero() {
+				start = known
+			}
+			fix("start date set on in-progress skill")
+			s.StartDate = &start
+		}
+		if s.CompletionDate != nil {
+			fix("completion date cleared from in-progress skill")
+			s.CompletionDate = nil
+		}
+	case SkillStatusCompleted:
+		if s.Progress != 100 {
+			fix("completed skill progress raised from %d%% to 100%%", s.Progress)
+			s.Progress = 100
+		}
+		if s.CompletionDate == nil {
+			fix("completion date set on completed skill")
+			s.CompletionDate = &known
+		}
+		if s.StartDate != nil && s.StartDate.After(*s.CompletionDate) {
+			fix("start date moved back to the completion date")
+			start := *s.CompletionDate
+			s.StartDate = &start
+		}
+	}
+
+	if s.Status != SkillStatusCompleted && s.Review != nil {
+		fix("review schedule dropped from unfinished skill")
+		s.Review = nil
+	}
+	return fixes
+}
@@ -7,9 +7,11 @@ import (
 )
 
 type Activity struct {
-	Name     string `bson:"name" json:"name"`
-	Time     string `bson:"time" json:"time"`
-	Category string `bson:"category" json:"category"`
+	ID       primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name     string              `bson:"name" json:"name"`
+	Time     string              `bson:"time" json:"time"`
+	Category string              `bson:"category" json:"category"`
+	SkillID  *primitive.ObjectID `bson:"skillId,omitempty" json:"skillId,omitempty"`
 }
 
 type DailyProgress struct {
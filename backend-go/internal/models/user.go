@@ -20,6 +20,16 @@ type User struct {
 	ResetPasswordExpire      time.Time          `bson:"resetPasswordExpire,omitempty" json:"-"`
 	PhoneVerificationCode    string             `bson:"phoneVerificationCode,omitempty" json:"-"`
 	PhoneVerificationExpire  time.Time          `bson:"phoneVerificationExpire,omitempty" json:"-"`
+	Timezone                 string             `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA name, e.g. "Asia/Kolkata"
+	ScheduleConflicts        string             `bson:"scheduleConflicts,omitempty" json:"scheduleConflicts,omitempty"` // "strict" or "warn"
+	CalendarFeedTokenHash    string             `bson:"calendarFeedTokenHash,omitempty" json:"-"`
 	CreatedAt                time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt                time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
+
+// Overlapping schedule items are either rejected (strict) or saved with the
+// overlaps reported back (warn). Users without a preference get warnings.
+const (
+	ScheduleConflictsStrict = "strict"
+	ScheduleConflictsWarn   = "warn"
+)
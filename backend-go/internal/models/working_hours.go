@@ -6,17 +6,56 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+type Mood string
+
+const (
+	MoodEnergetic  Mood = "Energetic"
+	MoodProductive Mood = "Productive"
+	MoodNormal     Mood = "Normal"
+	MoodTired      Mood = "Tired"
+	MoodDistracted Mood = "Distracted"
+)
+
+// MoodScale lists the moods from best to worst. A mood's score is its rank on
+// this scale, 5 for Energetic down to 1 for Distracted.
+var MoodScale = []Mood{MoodEnergetic, MoodProductive, MoodNormal, MoodTired, MoodDistracted}
+
+func (m Mood) Score() int {
+	for i, mood := range MoodScale {
+		if mood == m {
+			return len(MoodScale) - i
+		}
+	}
+	return 0
+}
+
+func (m Mood) IsValid() bool {
+	return m.Score() > 0
+}
+
+// Energy and focus are optional self-ratings on a 1-5 scale.
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
 type WorkingHours struct {
-	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	User          primitive.ObjectID `bson:"user" json:"user"`
-	Date          time.Time          `bson:"date" json:"date"`
-	TargetHours   float64            `bson:"targetHours" json:"targetHours"`
-	AchievedHours float64            `bson:"achievedHours" json:"achievedHours"`
-	Category      string             `bson:"category" json:"category"`
-	Notes         string             `bson:"notes,omitempty" json:"notes,omitempty"`
-	Mood          string             `bson:"mood" json:"mood"`
-	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt     time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	User          primitive.ObjectID  `bson:"user" json:"user"`
+	Date          time.Time           `bson:"date" json:"date"`
+	TargetHours   float64             `bson:"targetHours" json:"targetHours"`
+	AchievedHours float64             `bson:"achievedHours" json:"achievedHours"`
+	Category      string              `bson:"category" json:"category"`
+	Notes         string              `bson:"notes,omitempty" json:"notes,omitempty"`
+	Mood          Mood                `bson:"mood" json:"mood"`
+	Energy        *int                `bson:"energy,omitempty" json:"energy,omitempty"`
+	Focus         *int                `bson:"focus,omitempty" json:"focus,omitempty"`
+	SkillID       *primitive.ObjectID `bson:"skillId,omitempty" json:"skillId,omitempty"`
+	// FocusSessionIDs lists the completed focus sessions whose time is
+	// already counted in AchievedHours.
+	FocusSessionIDs []primitive.ObjectID `bson:"focusSessionIds,omitempty" json:"-"`
+	CreatedAt       time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time            `bson:"updatedAt" json:"updatedAt"`
 }
 
 // Virtual properties logic (ProgressPercentage, Status) will be handled in the Controller/Service layer or a method.
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GoalPeriod string
+
+const (
+	GoalPeriodDaily   GoalPeriod = "daily"
+	GoalPeriodWeekly  GoalPeriod = "weekly"
+	GoalPeriodMonthly GoalPeriod = "monthly"
+)
+
+func (p GoalPeriod) IsValid() bool {
+	switch p {
+	case GoalPeriodDaily, GoalPeriodWeekly, GoalPeriodMonthly:
+		return true
+	}
+	return false
+}
+
+// WorkingHoursGoal is an hour target for a period. An empty Category means the
+// goal counts hours logged in every category.
+type WorkingHoursGoal struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User        primitive.ObjectID `bson:"user" json:"user"`
+	Category    string             `bson:"category" json:"category"`
+	Period      GoalPeriod         `bson:"period" json:"period"`
+	TargetHours float64            `bson:"targetHours" json:"targetHours"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WeekdayTarget is the target for one weekday. An empty Category applies to
+// every category without a target of its own on that day.
+type WeekdayTarget struct {
+	Weekday     time.Weekday `bson:"weekday" json:"weekday"`
+	Category    string       `bson:"category,omitempty" json:"category,omitempty"`
+	TargetHours float64      `bson:"targetHours" json:"targetHours"`
+}
+
+// WorkingHoursTemplate holds a user's usual targets per weekday. When
+// PrefillCategory is set, a target-only entry in that category is created for
+// every day the user does not log, so missed days count as 0%.
+type WorkingHoursTemplate struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User            primitive.ObjectID `bson:"user" json:"user"`
+	Targets         []WeekdayTarget    `bson:"targets" json:"targets"`
+	PrefillCategory string             `bson:"prefillCategory,omitempty" json:"prefillCategory,omitempty"`
+	FilledThrough   time.Time          `bson:"filledThrough,omitempty" json:"filledThrough,omitempty"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// TargetFor returns the target for a category on a weekday, preferring a
+// category-specific target over the weekday's general one.
+func (t *WorkingHoursTemplate) TargetFor(weekday time.Weekday, category string) (float64, bool) {
+	hours, found := 0.0, false
+	for _, target := range t.Targets {
+		if target.Weekday != weekday {
+			continue
+		}
+		if target.Category == category {
+			return target.TargetHours, true
+		}
+		if target.Category == "" {
+			hours, found = target.TargetHours, true
+		}
+	}
+	return hours, found
+}
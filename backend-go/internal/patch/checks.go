@@ -0,0 +1,61 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NotBlank rejects strings that are empty once trimmed.
+func NotBlank(value interface{}) string {
+	if s, _ := value.(string); strings.TrimSpace(s) == "" {
+		return "cannot be empty"
+	}
+	return ""
+}
+
+// OneOf accepts only the listed strings.
+func OneOf(allowed ...string) func(interface{}) string {
+	return func(value interface{}) string {
+		s, _ := value.(string)
+		for _, a := range allowed {
+			if s == a {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(allowed, ", ")
+	}
+}
+
+// Between accepts numbers and integers within [min, max].
+func Between(min, max float64) func(interface{}) string {
+	return func(value interface{}) string {
+		var n float64
+		switch v := value.(type) {
+		case int:
+			n = float64(v)
+		case float64:
+			n = v
+		}
+		if n < min || n > max {
+			return fmt.Sprintf("must be between %g and %g", min, max)
+		}
+		return ""
+	}
+}
+
+// AtLeast accepts numbers and integers no smaller than min.
+func AtLeast(min float64) func(interface{}) string {
+	return func(value interface{}) string {
+		var n float64
+		switch v := value.(type) {
+		case int:
+			n = float64(v)
+		case float64:
+			n = v
+		}
+		if n < min {
+			return fmt.Sprintf("must be at least %g", min)
+		}
+		return ""
+	}
+}
@@ -0,0 +1,247 @@
+// Package patch implements JSON Merge Patch (RFC 7396) request bodies for the
+// update handlers. Each handler declares a Schema listing the fields a client
+// may change; anything else is rejected rather than written to the database.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Integer
+	Bool
+	// List fields are decoded into the type of Field.Of and, as RFC 7396
+	// requires for arrays, always replace the stored value wholesale.
+	List
+)
+
+func (k Kind) String() string {
+	switch k {
+	case String:
+		return "a string"
+	case Number:
+		return "a number"
+	case Integer:
+		return "an integer"
+	case Bool:
+		return "a boolean"
+	default:
+		return "an array"
+	}
+}
+
+type Field struct {
+	Kind Kind
+	// BSON is the document key the field is stored under. It defaults to
+	// the JSON name.
+	BSON string
+	// Nullable fields may be removed by sending null.
+	Nullable bool
+	// Of is a zero value of the slice type List fields decode into.
+	Of interface{}
+	// Check validates a decoded, non-null value and returns a message
+	// describing the problem, or an empty string.
+	Check func(value interface{}) string
+}
+
+// Schema maps JSON field names to the rules for changing them.
+type Schema map[string]Field
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every problem found in a patch so clients can fix
+// them all in one round trip.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := []string{}
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Field+" "+fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Patch holds the decoded changes keyed by JSON field name. A nil value means
+// the field is to be removed.
+type Patch struct {
+	Values map[string]interface{}
+	schema Schema
+}
+
+// Decode reads a merge patch document and checks every member against the
+// schema. Unknown fields, wrong types and failed checks are all reported
+// together in a *ValidationError.
+func Decode(body io.Reader, schema Schema) (*Patch, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("request body must be a JSON object")
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("request body must be a JSON object")
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	p := &Patch{Values: make(map[string]interface{}), schema: schema}
+	errs := []FieldError{}
+	for _, name := range names {
+		field, ok := schema[name]
+		if !ok {
+			errs = append(errs, FieldError{Field: name, Message: "cannot be updated"})
+			continue
+		}
+		value, msg := decodeValue(field, raw[name])
+		if msg == "" && value != nil && field.Check != nil {
+			msg = field.Check(value)
+		}
+		if msg != "" {
+			errs = append(errs, FieldError{Field: name, Message: msg})
+			continue
+		}
+		p.Values[name] = value
+	}
+
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+	return p, nil
+}
+
+func decodeValue(field Field, raw json.RawMessage) (interface{}, string) {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		if !field.Nullable {
+			return nil, "cannot be null"
+		}
+		return nil, ""
+	}
+
+	mismatch := "must be " + field.Kind.String()
+	switch field.Kind {
+	case String:
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			return nil, mismatch
+		}
+		return s, ""
+	case Number:
+		var f float64
+		if json.Unmarshal(raw, &f) != nil {
+			return nil, mismatch
+		}
+		return f, ""
+	case Integer:
+		var f float64
+		if json.Unmarshal(raw, &f) != nil || f != math.Trunc(f) {
+			return nil, mismatch
+		}
+		return int(f), ""
+	case Bool:
+		var b bool
+		if json.Unmarshal(raw, &b) != nil {
+			return nil, mismatch
+		}
+		return b, ""
+	case List:
+		target := reflect.New(reflect.TypeOf(field.Of))
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(target.Interface()); err != nil || target.Elem().Kind() != reflect.Slice {
+			return nil, mismatch
+		}
+		return target.Elem().Interface(), ""
+	}
+	return nil, mismatch
+}
+
+func (p *Patch) Has(name string) bool {
+	_, ok := p.Values[name]
+	return ok
+}
+
+func (p *Patch) bsonKey(name string) string {
+	if key := p.schema[name].BSON; key != "" {
+		return key
+	}
+	return name
+}
+
+// Update converts the patch into a MongoDB update document. Removed fields
+// become $unset; extra is merged into $set, typically for updatedAt.
+func (p *Patch) Update(extra bson.M) bson.M {
+	set := bson.M{}
+	unset := bson.M{}
+	for name, value := range p.Values {
+		if value == nil {
+			unset[p.bsonKey(name)] = ""
+		} else {
+			set[p.bsonKey(name)] = value
+		}
+	}
+	for k, v := range extra {
+		set[k] = v
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// Apply merges the patch into target, a pointer to a struct, following the
+// struct's JSON field names. It is used for documents embedded in arrays,
+// which cannot be updated with a plain $set.
+func (p *Patch) Apply(target interface{}) error {
+	current, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return err
+	}
+	for name, value := range p.Values {
+		if value == nil {
+			delete(doc, name)
+		} else {
+			doc[name] = value
+		}
+	}
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	// Decode into a fresh value so removed fields fall back to zero values.
+	fresh := reflect.New(reflect.TypeOf(target).Elem())
+	if err := json.Unmarshal(merged, fresh.Interface()); err != nil {
+		return err
+	}
+	reflect.ValueOf(target).Elem().Set(fresh.Elem())
+	return nil
+}
@@ -0,0 +1,367 @@
+package roadmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrPrerequisiteCycle is returned when a template is fine on its own but its
+// prerequisites close a cycle with ones the user already set.
+var ErrPrerequisiteCycle = errors.New("Template prerequisites would create a cycle with existing skills")
+
+// Report says what an import did, or would do on a dry run.
+type Report struct {
+	DryRun            bool     `json:"dryRun"`
+	CategoriesCreated []string `json:"categoriesCreated"`
+	SkillsCreated     []string `json:"skillsCreated"`
+	SkillsUpdated     []string `json:"skillsUpdated"`
+	SkillsUnchanged   int      `json:"skillsUnchanged"`
+	ResourcesAdded    int      `json:"resourcesAdded"`
+}
+
+// resourceKey matches a template resource against the ones a skill already
+// has, by URL when there is one and by title otherwise.
+func resourceKey(title, url string) string {
+	if url = strings.TrimSpace(url); url != "" {
+		return "url:" + strings.ToLower(url)
+	}
+	return "title:" + strings.ToLower(strings.TrimSpace(title))
+}
+
+// Import adds the template to the user's skills. Categories and skills are
+// matched by name, so importing the same template twice changes nothing the
+// second time. Skills the user already has keep their priority and order;
+// they only gain the template's missing resources and prerequisites, and a
+// description if they had none. Their status and progress are kept too,
+// unless the progress is derived from resources: then it follows the added
+// ones, with the review schedule and a progress event as through the API,
+// days in loc. New skills start as upcoming and are placed after the
+// category's existing skills.
+func Import(ctx context.Context, userObjID primitive.ObjectID, t *Template, loc *time.Location, dryRun bool) (*Report, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	cursor, err := database.GetCollection("skills").Find(ctx, bson.M{"user": userObjID})
+	if err != nil {
+		return nil, err
+	}
+	var existing []models.Skill
+	if err = cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+
+	cursor, err = database.GetCollection("categories").Find(ctx, bson.M{"user": userObjID, "type": models.CategoryTypeSkills})
+	if err != nil {
+		return nil, err
+	}
+	var categories []models.Category
+	if err = cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+
+	skills := make(map[skillKey]*models.Skill, len(existing))
+	byID := make(map[primitive.ObjectID]*models.Skill, len(existing))
+	nextOrder := make(map[string]int)
+	for i := range existing {
+		skill := &existing[i]
+		skills[skillKey{skill.Category, skill.Name}] = skill
+		byID[skill.ID] = skill
+		if skill.OrderIndex >= nextOrder[skill.Category] {
+			nextOrder[skill.Category] = skill.OrderIndex + 1
+		}
+	}
+	knownCategories := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		knownCategories[c.Name] = true
+	}
+
+	report := &Report{
+		DryRun:            dryRun,
+		CategoriesCreated: []string{},
+		SkillsCreated:     []string{},
+		SkillsUpdated:     []string{},
+	}
+	now := time.Now()
+	var newCategories []interface{}
+	var created []*models.Skill
+	changed := make(map[primitive.ObjectID]bool)
+
+	for _, c := range t.Categories {
+		if !knownCategories[c.Name] {
+			newCategories = append(newCategories, models.Category{
+				ID:        primitive.NewObjectID(),
+				User:      userObjID,
+				Name:      c.Name,
+				Type:      models.CategoryTypeSkills,
+				Color:     c.Color,
+				Icon:      c.Icon,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+			report.CategoriesCreated = append(report.CategoriesCreated, c.Name)
+		}
+
+		for _, s := range c.Skills {
+			skill, ok := skills[skillKey{c.Name, s.Name}]
+			if !ok {
+				skill = &models.Skill{
+					ID:          primitive.NewObjectID(),
+					User:        userObjID,
+					Category:    c.Name,
+					Name:        s.Name,
+					Status:      models.SkillStatusUpcoming,
+					Description: s.Description,
+					Resources:   []models.Resource{},
+					Priority:    models.SkillPriority(s.Priority),
+					OrderIndex:  nextOrder[c.Name],
+					CreatedAt:   now,
+					UpdatedAt:   now,
+				}
+				nextOrder[c.Name]++
+				skills[skillKey{c.Name, s.Name}] = skill
+				byID[skill.ID] = skill
+				created = append(created, skill)
+				report.SkillsCreated = append(report.SkillsCreated, c.Name+"/"+s.Name)
+			} else if skill.Description == "" && s.Description != "" {
+				skill.Description = s.Description
+				changed[skill.ID] = true
+			}
+
+			have := make(map[string]bool, len(skill.Resources))
+			for _, resource := range skill.Resources {
+				have[resourceKey(resource.Title, resource.URL)] = true
+			}
+			for _, resource := range s.Resources {
+				key := resourceKey(resource.Title, resource.URL)
+				if have[key] {
+					continue
+				}
+				have[key] = true
+				resourceType := models.ResourceType(resource.Type)
+				if resourceType == "" {
+					resourceType = models.ResourceTypeOther
+				}
+				skill.Resources = append(skill.Resources, models.Resource{
+					ID:     primitive.NewObjectID(),
+					Title:  strings.TrimSpace(resource.Title),
+					URL:    strings.TrimSpace(resource.URL),
+					Type:   resourceType,
+					Status: models.ResourceStatusNotStarted,
+				})
+				changed[skill.ID] = true
+				report.ResourcesAdded++
+			}
+		}
+	}
+
+	// Prerequisites are resolved once every template skill has an ID, and
+	// only ever added, so a skill keeps the ones its owner set by hand.
+	for _, c := range t.Categories {
+		for _, s := range c.Skills {
+			skill := skills[skillKey{c.Name, s.Name}]
+			for _, ref := range s.Prerequisites {
+				key, _ := t.resolve(ref)
+				prereqID := skills[key].ID
+				present := false
+				for _, id := range skill.Prerequisites {
+					present = present || id == prereqID
+				}
+				if !present {
+					skill.Prerequisites = append(skill.Prerequisites, prereqID)
+					changed[skill.ID] = true
+				}
+			}
+		}
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(byID))
+	for _, skill := range existing {
+		ids = append(ids, skill.ID)
+	}
+	for _, skill := range created {
+		ids = append(ids, skill.ID)
+	}
+	edges := func(id primitive.ObjectID) []primitive.ObjectID {
+		if skill, ok := byID[id]; ok {
+			return skill.Prerequisites
+		}
+		return nil
+	}
+	if cycle := findCycle(edges, ids); cycle != nil {
+		names := make([]string, len(cycle))
+		for i, id := range cycle {
+			names[i] = byID[id].Name
+		}
+		return nil, fmt.Errorf("%w: %s", ErrPrerequisiteCycle, strings.Join(names, " → "))
+	}
+
+	var writes []mongo.WriteModel
+	var events []interface{}
+	for _, skill := range created {
+		delete(changed, skill.ID)
+		writes = append(writes, mongo.NewInsertOneModel().SetDocument(skill))
+		events = append(events, models.SkillProgressEvent{
+			ID:         primitive.NewObjectID(),
+			User:       userObjID,
+			Skill:      skill.ID,
+			Category:   skill.Category,
+			ToStatus:   skill.Status,
+			ToProgress: skill.Progress,
+			CreatedAt:  now,
+		})
+	}
+	for _, skill := range existing {
+		if !changed[skill.ID] {
+			report.SkillsUnchanged++
+			continue
+		}
+		set := bson.M{"description": skill.Description, "updatedAt": now}
+		if len(skill.Resources) > 0 {
+			set["resources"] = skill.Resources
+		}
+		if len(skill.Prerequisites) > 0 {
+			set["prerequisites"] = skill.Prerequisites
+		}
+		// Progress derived from resources follows the new ones, as it does
+		// when resources are added through the API.
+		before := skill
+		derived, err := skill.SyncResourceProgress(now, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", skill.Category, skill.Name, err)
+		}
+		if derived {
+			set["status"] = skill.Status
+			set["progress"] = skill.Progress
+			set["startDate"] = skill.StartDate
+			set["completionDate"] = skill.CompletionDate
+			set["review"] = skill.Review
+		}
+		if skill.Status != before.Status || skill.Progress != before.Progress {
+			events = append(events, models.SkillProgressEvent{
+				ID:           primitive.NewObjectID(),
+				User:         userObjID,
+				Skill:        skill.ID,
+				Category:     skill.Category,
+				FromStatus:   before.Status,
+				ToStatus:     skill.Status,
+				FromProgress: before.Progress,
+				ToProgress:   skill.Progress,
+				CreatedAt:    now,
+			})
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": skill.ID, "user": userObjID}).
+			SetUpdate(bson.M{"$set": set}))
+		report.SkillsUpdated = append(report.SkillsUpdated, skill.Category+"/"+skill.Name)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	if len(newCategories) > 0 {
+		if _, err := database.GetCollection("categories").InsertMany(ctx, newCategories); err != nil {
+			return nil, err
+		}
+	}
+	if len(writes) > 0 {
+		if _, err := database.GetCollection("skills").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return nil, err
+		}
+	}
+	// Matches what AddSkill records for a new skill and what resource edits
+	// record for derived progress. The skills are saved already, so a
+	// failure only costs the timeline these entries.
+	if len(events) > 0 {
+		if _, err := database.GetCollection("skill_progress_events").InsertMany(ctx, events); err != nil {
+			log.Printf("⚠️ Failed to record progress for imported skills: %v", err)
+		}
+	}
+	return report, nil
+}
+
+// Export builds a template from the user's skills, in the order GetSkills
+// lists them, with each category's color and icon when it has one.
+// Status, progress and anything the user tracked on resources are left out.
+func Export(ctx context.Context, userObjID primitive.ObjectID, name string) (*Template, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "category", Value: 1}, {Key: "orderIndex", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := database.GetCollection("skills").Find(ctx, bson.M{"user": userObjID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var skills []models.Skill
+	if err = cursor.All(ctx, &skills); err != nil {
+		return nil, err
+	}
+
+	cursor, err = database.GetCollection("categories").Find(ctx, bson.M{"user": userObjID, "type": models.CategoryTypeSkills})
+	if err != nil {
+		return nil, err
+	}
+	var categories []models.Category
+	if err = cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	styles := make(map[string]models.Category, len(categories))
+	for _, c := range categories {
+		styles[c.Name] = c
+	}
+
+	byID := make(map[primitive.ObjectID]models.Skill, len(skills))
+	nameCount := make(map[string]int)
+	for _, skill := range skills {
+		byID[skill.ID] = skill
+		nameCount[skill.Name]++
+	}
+	ref := func(skill models.Skill) string {
+		if nameCount[skill.Name] > 1 {
+			return skill.Category + "/" + skill.Name
+		}
+		return skill.Name
+	}
+
+	t := &Template{Name: name, Categories: []Category{}}
+	for _, skill := range skills {
+		if len(t.Categories) == 0 || t.Categories[len(t.Categories)-1].Name != skill.Category {
+			style := styles[skill.Category]
+			t.Categories = append(t.Categories, Category{
+				Name:   skill.Category,
+				Color:  style.Color,
+				Icon:   style.Icon,
+				Skills: []Skill{},
+			})
+		}
+		s := Skill{
+			Name:        skill.Name,
+			Description: skill.Description,
+			Priority:    string(skill.Priority),
+		}
+		for _, id := range skill.Prerequisites {
+			if prereq, ok := byID[id]; ok {
+				s.Prerequisites = append(s.Prerequisites, ref(prereq))
+			}
+		}
+		for _, resource := range skill.Resources {
+			s.Resources = append(s.Resources, Resource{
+				Title: resource.Title,
+				URL:   resource.URL,
+				Type:  string(resource.Type),
+			})
+		}
+		c := &t.Categories[len(t.Categories)-1]
+		c.Skills = append(c.Skills, s)
+	}
+	return t, nil
+}
@@ -0,0 +1,259 @@
+// Package roadmap reads and writes shareable skill roadmap templates and
+// imports them into a user's skills.
+package roadmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"service-exchange-backend-go/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Template is a roadmap as it is shared between users. Skills are listed in
+// the order they should be learned within their category, and prerequisites
+// refer to other skills in the template by name (see resolve).
+type Template struct {
+	Name        string     `json:"name" yaml:"name"`
+	Description string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Categories  []Category `json:"categories" yaml:"categories"`
+}
+
+type Category struct {
+	Name   string  `json:"name" yaml:"name"`
+	Color  string  `json:"color,omitempty" yaml:"color,omitempty"`
+	Icon   string  `json:"icon,omitempty" yaml:"icon,omitempty"`
+	Skills []Skill `json:"skills" yaml:"skills"`
+}
+
+type Skill struct {
+	Name          string     `json:"name" yaml:"name"`
+	Description   string     `json:"description,omitempty" yaml:"description,omitempty"`
+	Priority      string     `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Prerequisites []string   `json:"prerequisites,omitempty" yaml:"prerequisites,omitempty"`
+	Resources     []Resource `json:"resources,omitempty" yaml:"resources,omitempty"`
+}
+
+type Resource struct {
+	Title string `json:"title" yaml:"title"`
+	URL   string `json:"url,omitempty" yaml:"url,omitempty"`
+	Type  string `json:"type,omitempty" yaml:"type,omitempty"`
+}
+
+// FormatOf picks the format from a file name, defaulting to YAML.
+func FormatOf(filename string) string {
+	if strings.HasSuffix(strings.ToLower(filename), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Parse reads a template in the given format and checks it with Validate.
+func Parse(data []byte, format string) (*Template, error) {
+	var t Template
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&t); err != nil {
+			return nil, fmt.Errorf("Invalid JSON: %v", err)
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&t); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("Invalid YAML: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("Format must be yaml or json")
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Encode writes the template in the given format.
+func Encode(w io.Writer, t *Template, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(t)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(t); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("Format must be yaml or json")
+}
+
+// Validate trims names and checks that the template can be imported: every
+// category and skill is named, skill names are unique within their category,
+// priorities and resource types are known, and prerequisites name skills in
+// the template without forming a cycle.
+func (t *Template) Validate() error {
+	if len(t.Categories) == 0 {
+		return fmt.Errorf("Template has no categories")
+	}
+
+	categories := make(map[string]bool)
+	skills := make(map[skillKey]*Skill)
+	var keys []skillKey
+	for ci := range t.Categories {
+		c := &t.Categories[ci]
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			return fmt.Errorf("Category %d has no name", ci+1)
+		}
+		if categories[c.Name] {
+			return fmt.Errorf("Category '%s' is listed twice", c.Name)
+		}
+		categories[c.Name] = true
+
+		for si := range c.Skills {
+			s := &c.Skills[si]
+			s.Name = strings.TrimSpace(s.Name)
+			if s.Name == "" {
+				return fmt.Errorf("Skill %d in '%s' has no name", si+1, c.Name)
+			}
+			key := skillKey{c.Name, s.Name}
+			if _, ok := skills[key]; ok {
+				return fmt.Errorf("Skill '%s' is listed twice in '%s'", s.Name, c.Name)
+			}
+			skills[key] = s
+			keys = append(keys, key)
+
+			switch models.SkillPriority(s.Priority) {
+			case "", models.SkillPriorityHigh, models.SkillPriorityMedium, models.SkillPriorityLow:
+			default:
+				return fmt.Errorf("Skill '%s' has unknown priority '%s'", s.Name, s.Priority)
+			}
+			for _, resource := range s.Resources {
+				if strings.TrimSpace(resource.Title) == "" && resource.URL == "" {
+					return fmt.Errorf("A resource of '%s' has neither a title nor a URL", s.Name)
+				}
+				switch models.ResourceType(resource.Type) {
+				case "", models.ResourceTypeCourse, models.ResourceTypeDocumentation, models.ResourceTypeTutorial,
+					models.ResourceTypeVideo, models.ResourceTypeBook, models.ResourceTypeOther:
+				default:
+					return fmt.Errorf("A resource of '%s' has unknown type '%s'", s.Name, resource.Type)
+				}
+			}
+		}
+	}
+
+	edges := make(map[skillKey][]skillKey)
+	for _, key := range keys {
+		for _, ref := range skills[key].Prerequisites {
+			prereq, err := t.resolve(ref)
+			if err != nil {
+				return fmt.Errorf("Skill '%s' needs %v", key.name, err)
+			}
+			edges[key] = append(edges[key], prereq)
+		}
+	}
+	if cycle := findCycle(func(key skillKey) []skillKey { return edges[key] }, keys); cycle != nil {
+		names := make([]string, len(cycle))
+		for i, key := range cycle {
+			names[i] = key.name
+		}
+		return fmt.Errorf("Prerequisites form a cycle: %s", strings.Join(names, " → "))
+	}
+	return nil
+}
+
+// skillKey identifies a skill the way the skills collection does, by its
+// category and name.
+type skillKey struct {
+	category string
+	name     string
+}
+
+// resolve finds the skill a prerequisite refers to. A plain name is enough
+// when only one category has a skill by that name; otherwise the reference
+// is written "Category/Name".
+func (t *Template) resolve(ref string) (skillKey, error) {
+	ref = strings.TrimSpace(ref)
+	var matches []skillKey
+	for _, c := range t.Categories {
+		for _, s := range c.Skills {
+			if s.Name == ref {
+				matches = append(matches, skillKey{c.Name, s.Name})
+			}
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	for _, c := range t.Categories {
+		name, ok := strings.CutPrefix(ref, c.Name+"/")
+		if !ok {
+			continue
+		}
+		for _, s := range c.Skills {
+			if s.Name == name {
+				return skillKey{c.Name, s.Name}, nil
+			}
+		}
+	}
+	if len(matches) > 1 {
+		return skillKey{}, fmt.Errorf("'%s', which is in more than one category; write it as Category/%s", ref, ref)
+	}
+	return skillKey{}, fmt.Errorf("'%s', which is not in the template", ref)
+}
+
+// findCycle returns a path that leads from a node back to itself, or nil if
+// the graph given by edges has no cycle. Nodes are visited in the given order
+// so the reported cycle does not depend on map iteration.
+func findCycle[T comparable](edges func(T) []T, nodes []T) []T {
+	const (
+		unvisited = iota
+		onPath
+		done
+	)
+	state := make(map[T]int)
+	var path []T
+	var walk func(node T) []T
+	walk = func(node T) []T {
+		state[node] = onPath
+		path = append(path, node)
+		for _, next := range edges(node) {
+			switch state[next] {
+			case onPath:
+				for i, n := range path {
+					if n == next {
+						return append(append([]T{}, path[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := walk(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = done
+		return nil
+	}
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			if cycle := walk(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,325 @@
+// Package rrule implements the part of RFC 5545 recurrence rules that makes
+// sense for whole-day schedules: FREQ=DAILY, WEEKLY or MONTHLY with INTERVAL,
+// BYDAY, BYMONTHDAY, WKST, COUNT and UNTIL. Occurrences are calendar days;
+// any time of day in DTSTART or UNTIL is ignored.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+var weekdayCodes = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// WeekdayNum is a BYDAY entry. N picks the Nth such weekday of the month,
+// counting from the end when negative; 0 means every one. N is only allowed
+// with FREQ=MONTHLY.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+func (w WeekdayNum) String() string {
+	if w.N == 0 {
+		return weekdayCodes[w.Weekday]
+	}
+	return strconv.Itoa(w.N) + weekdayCodes[w.Weekday]
+}
+
+// Rule is a parsed RRULE. Until is a calendar day, compared by date only.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	WeekStart  time.Weekday
+	Count      int
+	Until      *time.Time
+}
+
+func parseWeekday(code string) (time.Weekday, bool) {
+	for i, c := range weekdayCodes {
+		if c == code {
+			return time.Weekday(i), true
+		}
+	}
+	return 0, false
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102", "20060102T150405Z", "20060102T150405"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;BYDAY=MO,WE,FR", with or without
+// the "RRULE:" prefix. Parts this package cannot honour are rejected rather
+// than ignored.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.ToUpper(s), "RRULE:")
+	if s == "" {
+		return nil, errors.New("rule is empty")
+	}
+
+	rule := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s given more than once", name)
+		}
+		seen[name] = true
+
+		switch name {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly:
+				rule.Freq = Frequency(value)
+			default:
+				return nil, fmt.Errorf("FREQ must be DAILY, WEEKLY or MONTHLY, not %s", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive number")
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive number")
+			}
+			rule.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		case "WKST":
+			day, ok := parseWeekday(value)
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", value)
+			}
+			rule.WeekStart = day
+		case "BYDAY":
+			for _, entry := range strings.Split(value, ",") {
+				code := entry[max(len(entry)-2, 0):]
+				day, ok := parseWeekday(code)
+				if !ok {
+					return nil, fmt.Errorf("invalid BYDAY %q", entry)
+				}
+				n := 0
+				if prefix := entry[:len(entry)-2]; prefix != "" {
+					var err error
+					n, err = strconv.Atoi(prefix)
+					if err != nil || n == 0 || n < -5 || n > 5 {
+						return nil, fmt.Errorf("invalid BYDAY %q", entry)
+					}
+				}
+				rule.ByDay = append(rule.ByDay, WeekdayNum{Weekday: day, N: n})
+			}
+		case "BYMONTHDAY":
+			for _, entry := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(entry)
+				if err != nil || n == 0 || n < -31 || n > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", entry)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, n)
+			}
+		default:
+			return nil, fmt.Errorf("%s is not supported", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL cannot both be given")
+	}
+	if rule.Freq != Monthly {
+		for _, day := range rule.ByDay {
+			if day.N != 0 {
+				return nil, fmt.Errorf("BYDAY %s needs FREQ=MONTHLY", day)
+			}
+		}
+	}
+	if rule.Freq == Weekly && len(rule.ByMonthDay) > 0 {
+		return nil, errors.New("BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	return rule, nil
+}
+
+// String formats the rule as an RRULE value, without the "RRULE:" prefix.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			days[i] = day.String()
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayCodes[r.WeekStart])
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// civilDay numbers calendar days so that differences ignore DST shifts.
+func civilDay(t time.Time) int {
+	y, m, d := t.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func (r *Rule) matchesMonthDay(day time.Time) bool {
+	last := daysInMonth(day)
+	for _, n := range r.ByMonthDay {
+		if n == day.Day() || (n < 0 && last+n+1 == day.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesWeekday(day time.Time) bool {
+	for _, w := range r.ByDay {
+		if w.Weekday != day.Weekday() {
+			continue
+		}
+		switch {
+		case w.N == 0:
+			return true
+		case w.N > 0 && (day.Day()-1)/7+1 == w.N:
+			return true
+		case w.N < 0 && (daysInMonth(day)-day.Day())/7+1 == -w.N:
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether day is produced by the rule for a series starting
+// on start, leaving COUNT aside.
+func (r *Rule) matches(start, day time.Time) bool {
+	if civilDay(day) < civilDay(start) {
+		return false
+	}
+	if r.Until != nil && civilDay(day) > civilDay(*r.Until) {
+		return false
+	}
+
+	switch r.Freq {
+	case Daily:
+		if (civilDay(day)-civilDay(start))%r.Interval != 0 {
+			return false
+		}
+	case Weekly:
+		weekOf := func(t time.Time) int {
+			return civilDay(t) - (int(t.Weekday())-int(r.WeekStart)+7)%7
+		}
+		if (weekOf(day)-weekOf(start))/7%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+	case Monthly:
+		months := (day.Year()-start.Year())*12 + int(day.Month()) - int(start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			return day.Day() == start.Day()
+		}
+	}
+
+	if len(r.ByMonthDay) > 0 && !r.matchesMonthDay(day) {
+		return false
+	}
+	if len(r.ByDay) > 0 && !r.matchesWeekday(day) {
+		return false
+	}
+	return true
+}
+
+// Between returns the days from from through to, inclusive, on which a
+// series starting on start occurs, in order. Days are midnight in start's
+// location. With COUNT, occurrences before from still use up the count.
+func (r *Rule) Between(start, from, to time.Time) []time.Time {
+	loc := start.Location()
+	day := func(t time.Time) time.Time {
+		y, m, d := t.In(loc).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	}
+	start, from, to = day(start), day(from), day(to)
+
+	walk := from
+	if r.Count > 0 || walk.Before(start) {
+		walk = start
+	}
+
+	days := []time.Time{}
+	count := 0
+	for d := walk; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if r.Until != nil && civilDay(d) > civilDay(*r.Until) {
+			break
+		}
+		if !r.matches(start, d) {
+			continue
+		}
+		count++
+		if r.Count > 0 && count > r.Count {
+			break
+		}
+		if !d.Before(from) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// Occurs reports whether a series starting on start has an occurrence on day.
+func (r *Rule) Occurs(start, day time.Time) bool {
+	days := r.Between(start, day, day)
+	return len(days) > 0
+}
@@ -0,0 +1,210 @@
+package rrule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY"},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR", "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{"freq=daily;interval=2", "FREQ=DAILY;INTERVAL=2"},
+		{"FREQ=WEEKLY;WKST=SU;INTERVAL=2;BYDAY=TU,SU", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,SU;WKST=SU"},
+		{"FREQ=WEEKLY;WKST=MO", "FREQ=WEEKLY"},
+		{"FREQ=MONTHLY;BYDAY=-1FR,2MO", "FREQ=MONTHLY;BYDAY=-1FR,2MO"},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", "FREQ=MONTHLY;BYMONTHDAY=1,-1"},
+		{"FREQ=DAILY;COUNT=10", "FREQ=DAILY;COUNT=10"},
+		{"FREQ=DAILY;UNTIL=20261231T235959Z", "FREQ=DAILY;UNTIL=20261231"},
+		{"FREQ=DAILY;UNTIL=20261231", "FREQ=DAILY;UNTIL=20261231"},
+	}
+	for _, tt := range tests {
+		rule, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	tests := []string{
+		"",
+		"RRULE:",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=3;UNTIL=20260101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;BYDAY",
+		"FREQ=WEEKLY;WKST=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=-32",
+	}
+	for _, in := range tests {
+		if rule, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", in, rule)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		start    string
+		from, to string
+		want     []string
+	}{
+		{
+			name:  "daily interval",
+			rule:  "FREQ=DAILY;INTERVAL=3",
+			start: "2026-01-01", from: "2026-01-05", to: "2026-01-12",
+			want: []string{"2026-01-07", "2026-01-10"},
+		},
+		{
+			name:  "weekly defaults to the start weekday",
+			rule:  "FREQ=WEEKLY",
+			start: "2026-01-06", from: "2026-01-01", to: "2026-01-31",
+			want: []string{"2026-01-06", "2026-01-13", "2026-01-20", "2026-01-27"},
+		},
+		// The two WKST examples from RFC 5545 section 3.3.10.
+		{
+			name:  "interval with WKST=MO",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=MO",
+			start: "1997-08-05", from: "1997-08-01", to: "1997-09-30",
+			want: []string{"1997-08-05", "1997-08-10", "1997-08-19", "1997-08-24"},
+		},
+		{
+			name:  "interval with WKST=SU",
+			rule:  "FREQ=WEEKLY;INTERVAL=2;COUNT=4;BYDAY=TU,SU;WKST=SU",
+			start: "1997-08-05", from: "1997-08-01", to: "1997-09-30",
+			want: []string{"1997-08-05", "1997-08-17", "1997-08-19", "1997-08-31"},
+		},
+		{
+			name:  "last Friday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=-1FR",
+			start: "2026-01-01", from: "2026-01-01", to: "2026-04-30",
+			want: []string{"2026-01-30", "2026-02-27", "2026-03-27", "2026-04-24"},
+		},
+		{
+			name:  "second Monday of the month",
+			rule:  "FREQ=MONTHLY;BYDAY=2MO",
+			start: "2026-01-01", from: "2026-01-01", to: "2026-03-31",
+			want: []string{"2026-01-12", "2026-02-09", "2026-03-09"},
+		},
+		{
+			name:  "last day of the month",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2026-01-15", from: "2026-01-01", to: "2026-04-30",
+			want: []string{"2026-01-31", "2026-02-28", "2026-03-31", "2026-04-30"},
+		},
+		{
+			name:  "second to last day in a leap year",
+			rule:  "FREQ=MONTHLY;BYMONTHDAY=-2",
+			start: "2028-02-01", from: "2028-02-01", to: "2028-03-31",
+			want: []string{"2028-02-28", "2028-03-30"},
+		},
+		{
+			name:  "monthly on the 31st skips short months",
+			rule:  "FREQ=MONTHLY",
+			start: "2026-01-31", from: "2026-01-01", to: "2026-05-31",
+			want: []string{"2026-01-31", "2026-03-31", "2026-05-31"},
+		},
+		{
+			name:  "count used up partly before the range",
+			rule:  "FREQ=DAILY;COUNT=5",
+			start: "2026-01-01", from: "2026-01-04", to: "2026-01-10",
+			want: []string{"2026-01-04", "2026-01-05"},
+		},
+		{
+			name:  "count used up entirely before the range",
+			rule:  "FREQ=WEEKLY;COUNT=2",
+			start: "2026-01-01", from: "2026-01-20", to: "2026-02-28",
+			want: []string{},
+		},
+		{
+			name:  "until is inclusive",
+			rule:  "FREQ=WEEKLY;UNTIL=20260120T120000Z",
+			start: "2026-01-06", from: "2026-01-01", to: "2026-02-28",
+			want: []string{"2026-01-06", "2026-01-13", "2026-01-20"},
+		},
+		{
+			name:  "range before the start",
+			rule:  "FREQ=DAILY",
+			start: "2026-03-01", from: "2026-02-01", to: "2026-02-28",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.rule, err)
+			}
+			got := []string{}
+			for _, day := range rule.Between(date(tt.start), date(tt.from), date(tt.to)) {
+				got = append(got, day.Format("2006-01-02"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Between = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBetweenKeepsLocalMidnight(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	rule, _ := Parse("FREQ=DAILY")
+	start := time.Date(2026, 3, 7, 9, 30, 0, 0, loc)
+	days := rule.Between(start, start, time.Date(2026, 3, 9, 0, 0, 0, 0, loc))
+	if len(days) != 3 {
+		t.Fatalf("got %d days across the DST change, want 3", len(days))
+	}
+	for _, day := range days {
+		if day.Location() != loc || day.Hour() != 0 || day.Minute() != 0 {
+			t.Errorf("day %v is not midnight in %s", day, loc)
+		}
+	}
+}
+
+func TestOccurs(t *testing.T) {
+	rule, _ := Parse("FREQ=WEEKLY;BYDAY=MO,WE")
+	start := date("2026-01-05")
+	tests := map[string]bool{
+		"2026-01-05": true,
+		"2026-01-06": false,
+		"2026-01-07": true,
+		"2026-01-04": false,
+	}
+	for day, want := range tests {
+		if got := rule.Occurs(start, date(day)); got != want {
+			t.Errorf("Occurs(%s) = %v, want %v", day, got, want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package scheduleimport turns parsed iCalendar events into schedule items.
+// It is shared by POST /api/schedules/import and cmd/import-ics.
+package scheduleimport
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/ical"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/rrule"
+	"service-exchange-backend-go/internal/scheduletemplates"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CategoryRule files events whose summary contains Keyword, ignoring case,
+// under Category.
+type CategoryRule struct {
+	Keyword  string `json:"keyword"`
+	Category string `json:"category"`
+}
+
+// Options picks the days to import, From through To in Location, and how
+// events are categorised: by the first matching rule, then the event's own
+// first category, then DefaultCategory. Strict refuses the days on which an
+// imported item would overlap another item, as for users who chose strict
+// schedule conflicts.
+type Options struct {
+	From            time.Time
+	To              time.Time
+	Location        *time.Location
+	Rules           []CategoryRule
+	DefaultCategory string
+	Strict          bool
+	DryRun          bool
+}
+
+// ErrScheduleCreated means another request created a schedule on one of the
+// days while the import ran. Importing again adds what is missing.
+var ErrScheduleCreated = errors.New("a schedule was created on one of the days during the import, import again to add the rest")
+
+// SkippedEvent is an event, or one occurrence of it, that was not imported.
+type SkippedEvent struct {
+	UID     string `json:"uid"`
+	Summary string `json:"summary"`
+	Date    string `json:"date,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// ConflictEntry is one side of a Conflict.
+type ConflictEntry struct {
+	Title     string `json:"title"`
+	StartTime string `json:"startTime"`
+	EndTime   string `json:"endTime"`
+}
+
+// Conflict is a pair of overlapping items on a day the import added to, at
+// least one of them imported.
+type Conflict struct {
+	Date string        `json:"date"`
+	Item ConflictEntry `json:"item"`
+	With ConflictEntry `json:"with"`
+}
+
+// Report says what an import did, or would do on a dry run. In strict mode
+// the conflicts are those of the refused days, whose events are also listed
+// as skipped.
+type Report struct {
+	DryRun           bool           `json:"dryRun"`
+	SchedulesCreated int            `json:"schedulesCreated"`
+	SchedulesUpdated int            `json:"schedulesUpdated"`
+	ItemsAdded       int            `json:"itemsAdded"`
+	Duplicates       int            `json:"duplicates"`
+	Skipped          []SkippedEvent `json:"skipped"`
+	Conflicts        []Conflict     `json:"conflicts"`
+}
+
+// occurrence is one day of an event, as the item it becomes.
+type occurrence struct {
+	day  time.Time
+	item models.ScheduleItem
+}
+
+func (o *Options) category(e ical.Event) string {
+	summary := strings.ToLower(e.Summary)
+	for _, rule := range o.Rules {
+		keyword := strings.ToLower(strings.TrimSpace(rule.Keyword))
+		if keyword != "" && strings.Contains(summary, keyword) {
+			return rule.Category
+		}
+	}
+	if len(e.Categories) > 0 {
+		return e.Categories[0]
+	}
+	return o.DefaultCategory
+}
+
+// itemFor turns an event into a schedule item for the day it starts on.
+// Items cannot cross midnight, so an event running into the next day is cut
+// off at the end of its first day, written as an end time of 00:00.
+func (o *Options) itemFor(e ical.Event, start, end time.Time) (models.ScheduleItem, error) {
+	if !end.After(start) {
+		return models.ScheduleItem{}, errors.New("event has no duration")
+	}
+	if midnight := timeutil.StartOfDay(start, o.Location).AddDate(0, 0, 1); end.After(midnight) {
+		end = midnight
+	}
+	title := e.Summary
+	if title == "" {
+		title = "Untitled event"
+	}
+	item := models.ScheduleItem{
+		Title:       title,
+		Description: e.Description,
+		StartTime:   start.Format("15:04"),
+		EndTime:     end.Format("15:04"),
+		Category:    o.category(e),
+		Priority:    "Medium",
+		ImportUID:   e.UID,
+	}
+	if _, _, err := item.Window(); err != nil {
+		return models.ScheduleItem{}, err
+	}
+	return item, nil
+}
+
+// occurrences expands the events into the days of the import range.
+// Recurring events are expanded with their RRULE, less their EXDATEs and
+// the days a RECURRENCE-ID override moved elsewhere; the overrides are
+// imported as events of their own.
+func (o *Options) occurrences(events []ical.Event, report *Report) []occurrence {
+	dayKey := func(t time.Time) string { return t.In(o.Location).Format(timeutil.DateLayout) }
+	overridden := map[string]bool{}
+	for _, e := range events {
+		if e.RecurrenceID != nil {
+			overridden[e.UID+"|"+dayKey(*e.RecurrenceID)] = true
+		}
+	}
+	skip := func(e ical.Event, day, reason string) {
+		report.Skipped = append(report.Skipped, SkippedEvent{UID: e.UID, Summary: e.Summary, Date: day, Reason: reason})
+	}
+
+	var result []occurrence
+	for _, e := range events {
+		if e.Status == "CANCELLED" {
+			continue
+		}
+		if e.AllDay {
+			skip(e, "", "all-day events have no time to schedule")
+			continue
+		}
+
+		startDay := timeutil.StartOfDay(e.Start, o.Location)
+		days := []time.Time{}
+		if e.RRule != "" && e.RecurrenceID == nil {
+			rule, err := rrule.Parse(e.RRule)
+			if err != nil {
+				skip(e, "", "unsupported recurrence: "+err.Error())
+				continue
+			}
+			excluded := map[string]bool{}
+			for _, ex := range e.ExDates {
+				excluded[dayKey(ex)] = true
+			}
+			for _, day := range rule.Between(startDay, o.From, o.To) {
+				if !excluded[dayKey(day)] && !overridden[e.UID+"|"+dayKey(day)] {
+					days = append(days, day)
+				}
+			}
+		} else if !startDay.Before(o.From) && !startDay.After(o.To) {
+			days = append(days, startDay)
+		}
+
+		for _, day := range days {
+			// Each occurrence keeps the event's wall-clock times.
+			y, m, d := day.Date()
+			start := time.Date(y, m, d, e.Start.Hour(), e.Start.Minute(), 0, 0, o.Location)
+			end := start.Add(e.End.Sub(e.Start))
+			item, err := o.itemFor(e, start, end)
+			if err != nil {
+				skip(e, dayKey(day), err.Error())
+				continue
+			}
+			result = append(result, occurrence{day: day, item: item})
+		}
+	}
+	return result
+}
+
+func conflictEntry(item models.ScheduleItem) ConflictEntry {
+	return ConflictEntry{Title: item.Title, StartTime: item.StartTime, EndTime: item.EndTime}
+}
+
+// Import adds the events that fall in the range to the user's schedules,
+// one schedule per date as CreateSchedule keeps them. Days without a
+// schedule get a new one; days with one have the items appended. An event
+// already imported onto a day, recognised by its UID, is counted as a
+// duplicate and left alone, so importing the same file again changes
+// nothing. The user's schedule templates are filled in over the range
+// first. Overlaps involving imported items are reported, and in strict mode
+// their days are left as they were.
+func Import(ctx context.Context, userObjID primitive.ObjectID, events []ical.Event, opts Options) (*Report, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.DefaultCategory == "" {
+		opts.DefaultCategory = "Other"
+	}
+	opts.From = timeutil.StartOfDay(opts.From, opts.Location)
+	opts.To = timeutil.StartOfDay(opts.To, opts.Location)
+	if opts.To.Before(opts.From) {
+		return nil, errors.New("the import range ends before it starts")
+	}
+
+	// Template occurrences are created first so imported items join them
+	// instead of taking their dates for good.
+	if !opts.DryRun {
+		if err := scheduletemplates.MaterializeRange(ctx, userObjID, opts.From, opts.To, opts.Location); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &Report{DryRun: opts.DryRun, Skipped: []SkippedEvent{}, Conflicts: []Conflict{}}
+	occurrences := opts.occurrences(events, report)
+
+	collection := database.GetCollection("schedules")
+	cursor, err := collection.Find(ctx, bson.M{
+		"user": userObjID,
+		"date": bson.M{"$gte": opts.From, "$lt": opts.To.AddDate(0, 0, 1)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var existing []models.Schedule
+	if err = cursor.All(ctx, &existing); err != nil {
+		return nil, err
+	}
+	byDay := map[string]*models.Schedule{}
+	for i := range existing {
+		byDay[existing[i].Date.In(opts.Location).Format(timeutil.DateLayout)] = &existing[i]
+	}
+
+	now := time.Now()
+	created := map[string]*models.Schedule{}
+	// kept is how many items each changed day had before the import.
+	kept := map[string]int{}
+	var order []string
+	for _, o := range occurrences {
+		key := o.day.Format(timeutil.DateLayout)
+		schedule := byDay[key]
+		if schedule == nil {
+			schedule = &models.Schedule{
+				ID:        primitive.NewObjectID(),
+				User:      userObjID,
+				Date:      o.day,
+				DayType:   models.ScheduleDayType(o.day),
+				Items:     []models.ScheduleItem{},
+				CreatedAt: now,
+			}
+			byDay[key] = schedule
+			created[key] = schedule
+		}
+
+		duplicate := false
+		for _, item := range schedule.Items {
+			if o.item.ImportUID != "" && item.ImportUID == o.item.ImportUID {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			report.Duplicates++
+			continue
+		}
+
+		if _, ok := kept[key]; !ok {
+			kept[key] = len(schedule.Items)
+			order = append(order, key)
+		}
+		o.item.ID = primitive.NewObjectID()
+		schedule.Items = append(schedule.Items, o.item)
+	}
+
+	var inserts []interface{}
+	var updates []mongo.WriteModel
+	for _, key := range order {
+		schedule := byDay[key]
+		imported := schedule.Items[kept[key]:]
+		isImported := map[primitive.ObjectID]bool{}
+		for _, item := range imported {
+			isImported[item.ID] = true
+		}
+		var conflicts []Conflict
+		for _, overlap := range models.FindOverlaps(schedule.Items) {
+			if isImported[overlap.Item.ID] || isImported[overlap.With.ID] {
+				conflicts = append(conflicts, Conflict{Date: key, Item: conflictEntry(overlap.Item), With: conflictEntry(overlap.With)})
+			}
+		}
+		report.Conflicts = append(report.Conflicts, conflicts...)
+		if opts.Strict && len(conflicts) > 0 {
+			for _, item := range imported {
+				report.Skipped = append(report.Skipped, SkippedEvent{
+					UID:     item.ImportUID,
+					Summary: item.Title,
+					Date:    key,
+					Reason:  "the day would have overlapping items",
+				})
+			}
+			continue
+		}
+		report.ItemsAdded += len(imported)
+
+		schedule.CalculateStats()
+		schedule.UpdatedAt = now
+		if created[key] != nil {
+			inserts = append(inserts, schedule)
+			continue
+		}
+		// Imported items count as an edit of a template's occurrence.
+		if schedule.TemplateID != nil {
+			schedule.Modified = true
+		}
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": schedule.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"items":      schedule.Items,
+				"totalHours": schedule.TotalHours,
+				"status":     schedule.Status,
+				"modified":   schedule.Modified,
+				"updatedAt":  schedule.UpdatedAt,
+			}}))
+	}
+	report.SchedulesCreated = len(inserts)
+	report.SchedulesUpdated = len(updates)
+
+	if opts.DryRun {
+		return report, nil
+	}
+	if len(inserts) > 0 {
+		_, err := collection.InsertMany(ctx, inserts)
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrScheduleCreated
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(updates) > 0 {
+		if _, err := collection.BulkWrite(ctx, updates); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,129 @@
+// Package scheduletemplates creates the schedules that recurring schedule
+// templates produce. Occurrences are created as their dates are read, so
+// everything that writes schedules for a date range, the API and
+// cmd/import-ics alike, fills in the templates first.
+package scheduletemplates
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"service-exchange-backend-go/internal/database"
+	"service-exchange-backend-go/internal/models"
+	"service-exchange-backend-go/internal/rrule"
+	"service-exchange-backend-go/internal/timeutil"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Find returns the templates matching filter, oldest first.
+func Find(ctx context.Context, filter bson.M) ([]models.ScheduleTemplate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	cursor, err := database.GetCollection("scheduletemplates").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	templates := []models.ScheduleTemplate{}
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// Materialize creates the schedules the templates produce from from through
+// to, days in loc. Dates that already have a schedule are left alone, and
+// when templates overlap the oldest one wins. It returns how many schedules
+// were created.
+func Materialize(ctx context.Context, userObjID primitive.ObjectID, templates []models.ScheduleTemplate, from, to time.Time, loc *time.Location) (int, error) {
+	if len(templates) == 0 || to.Before(from) {
+		return 0, nil
+	}
+	collection := database.GetCollection("schedules")
+
+	opts := options.Find().SetProjection(bson.M{"date": 1})
+	cursor, err := collection.Find(ctx, bson.M{
+		"user": userObjID,
+		"date": bson.M{"$gte": from, "$lt": to.AddDate(0, 0, 1)},
+	}, opts)
+	if err != nil {
+		return 0, err
+	}
+	var existing []models.Schedule
+	if err = cursor.All(ctx, &existing); err != nil {
+		return 0, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, s := range existing {
+		taken[s.Date.In(loc).Format(timeutil.DateLayout)] = true
+	}
+
+	now := time.Now()
+	var writes []mongo.WriteModel
+	for i := range templates {
+		template := &templates[i]
+		rule, err := rrule.Parse(template.RRule)
+		if err != nil {
+			log.Printf("⚠️ Skipping schedule template %s: %v", template.ID.Hex(), err)
+			continue
+		}
+		templateID := template.ID
+		for _, day := range rule.Between(timeutil.StartOfDay(template.StartDate, loc), from, to) {
+			key := day.Format(timeutil.DateLayout)
+			if taken[key] {
+				continue
+			}
+			items, ok := template.ItemsOn(day)
+			if !ok {
+				continue
+			}
+			taken[key] = true
+
+			schedule := models.Schedule{
+				ID:         primitive.NewObjectID(),
+				User:       userObjID,
+				Date:       day,
+				DayType:    models.ScheduleDayType(day),
+				Items:      items,
+				TemplateID: &templateID,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			}
+			schedule.CalculateStats()
+			// Upserting on the date, which the unique {user, date}
+			// index backs, keeps a concurrent request from creating
+			// the same occurrence twice.
+			writes = append(writes, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"user": userObjID, "date": day}).
+				SetUpdate(bson.M{"$setOnInsert": schedule}).
+				SetUpsert(true))
+		}
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+	// A duplicate key means another request created that day first, which
+	// is just as good.
+	res, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return 0, err
+	}
+	if res == nil {
+		return 0, nil
+	}
+	return int(res.UpsertedCount), nil
+}
+
+// MaterializeRange creates the occurrences of all of the user's templates
+// from from through to.
+func MaterializeRange(ctx context.Context, userObjID primitive.ObjectID, from, to time.Time, loc *time.Location) error {
+	templates, err := Find(ctx, bson.M{"user": userObjID})
+	if err != nil {
+		return err
+	}
+	_, err = Materialize(ctx, userObjID, templates, from, to, loc)
+	return err
+}
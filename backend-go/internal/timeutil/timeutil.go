@@ -0,0 +1,60 @@
+package timeutil
+
+import (
+	"fmt"
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// Location resolves an IANA timezone name, falling back to UTC for empty or
+// unknown names so callers always get a usable location.
+func Location(name string) *time.Location {
+	if !IsValidTimezone(name) {
+		return time.UTC
+	}
+	loc, _ := time.LoadLocation(name)
+	return loc
+}
+
+// IsValidTimezone reports whether name is an IANA timezone. "Local" is
+// refused: it would mean the server's own zone, not the user's.
+func IsValidTimezone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// StartOfDay returns midnight of the calendar day t falls on in loc. Every
+// dated document (working hours, schedules) is stored at this instant.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// StartOfWeek returns midnight of the Monday of t's week in loc.
+func StartOfWeek(t time.Time, loc *time.Location) time.Time {
+	day := StartOfDay(t, loc)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// EndOfWeek returns the last second of the Sunday closing t's week in loc.
+func EndOfWeek(t time.Time, loc *time.Location) time.Time {
+	return StartOfWeek(t, loc).AddDate(0, 0, 7).Add(-time.Second)
+}
+
+// ParseDate accepts either an RFC 3339 timestamp or a plain YYYY-MM-DD date.
+// Plain dates are read as calendar days in loc.
+func ParseDate(value string, loc *time.Location) (time.Time, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date.In(loc), nil
+	}
+	date, err := time.ParseInLocation(DateLayout, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}